@@ -5,6 +5,7 @@ package hotelbeds
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -24,6 +25,43 @@ type AuditData struct {
 	Internal     string       `json:"internal"`
 }
 
+// SafeProcessTime returns process time of the request, it's zero
+// if audit data is absent in the response (nil receiver).
+func (a *AuditData) SafeProcessTime() time.Duration {
+	if a == nil {
+		return 0
+	}
+	return a.ProcessTime.Duration()
+}
+
+// decodeAuditData decodes only the top-level auditData of the response body, other values
+// are skipped without decoding. Scan stops at auditData, which the API sends first.
+// It returns nil if the body isn't a JSON object or has no auditData.
+func decodeAuditData(body []byte) *AuditData {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		if key == "auditData" {
+			var audit AuditData
+			if dec.Decode(&audit) != nil {
+				return nil
+			}
+			return &audit
+		}
+		var skip json.RawMessage
+		if dec.Decode(&skip) != nil {
+			return nil
+		}
+	}
+	return nil
+}
+
 type ProcessTime time.Duration
 
 func (t *ProcessTime) UnmarshalJSON(data []byte) error {
@@ -35,6 +73,11 @@ func (t *ProcessTime) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Duration returns process time, which is reported in milliseconds.
+func (t ProcessTime) Duration() time.Duration {
+	return time.Duration(t) * time.Millisecond
+}
+
 type Hosts []string
 
 func (rh *Hosts) UnmarshalJSON(data []byte) error {
@@ -48,16 +91,37 @@ func (rh *Hosts) UnmarshalJSON(data []byte) error {
 
 type Environments []string
 
+// UnmarshalJSON decodes environments from a JSON array, a plain string
+// or a comma-delimited string optionally wrapped in brackets.
 func (rh *Environments) UnmarshalJSON(data []byte) error {
-	if len(data) == 0 {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
 		return nil
 	}
-	str := trimUnescapeQuotes(data)
-	if str[0] == '[' {
-		str = str[1 : len(str)-1]
+
+	var elems []string
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return fmt.Errorf("failed to parse Environments: %w", err)
+		}
+	} else {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return fmt.Errorf("failed to parse Environments: %w", err)
+		}
+		str = strings.TrimSpace(str)
+		str = strings.TrimPrefix(str, "[")
+		str = strings.TrimSuffix(str, "]")
+		elems = strings.Split(str, ",")
 	}
-	str = strings.ReplaceAll(str, " ", "")
-	*rh = strings.Split(str, ",")
+
+	envs := make(Environments, 0, len(elems))
+	for _, elem := range elems {
+		if elem = strings.TrimSpace(elem); elem != "" {
+			envs = append(envs, elem)
+		}
+	}
+	*rh = envs
 	return nil
 }
 
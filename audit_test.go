@@ -0,0 +1,63 @@
+// Copyright (c) 2024 0x9ef. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+package hotelbeds
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestEnvironmentsUnmarshalJSON(t *testing.T) {
+	for raw, want := range map[string]Environments{
+		`"production"`:                           {"production"},
+		`"live, awseucentral1, k8s"`:             {"live", "awseucentral1", "k8s"},
+		`"[live, awseucentral1, k8s]"`:           {"live", "awseucentral1", "k8s"},
+		`["awseucentral1", "ip_10_214_128_106"]`: {"awseucentral1", "ip_10_214_128_106"},
+		"[\n  \"live\",\n  \"k8s\"\n]":           {"live", "k8s"},
+		`""`:                                     {},
+	} {
+		var envs Environments
+		assert.NoError(t, json.Unmarshal([]byte(raw), &envs), raw)
+		assert.Equal(t, want, envs, raw)
+	}
+
+	var envs Environments
+	assert.Error(t, json.Unmarshal([]byte(`123`), &envs))
+}
+
+func TestAuditDataAbsent(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		Reply(200).
+		JSON(map[string]any{"boards": []any{map[string]any{"code": "BB"}}})
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		Reply(500).
+		JSON(map[string]string{"error": "System error"})
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	resp, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+	assert.Nil(t, resp.Audit)
+	assert.Equal(t, time.Duration(0), resp.Audit.SafeProcessTime())
+
+	_, err = client.ListBoards(context.TODO(), &ListBoardsInput{})
+	var apiErr *Error
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Nil(t, apiErr.Audit)
+	assert.Equal(t, time.Duration(0), apiErr.Audit.SafeProcessTime())
+	assert.Equal(t, "", apiErr.ServerID())
+	assert.Equal(t, "", apiErr.Token())
+
+	audit := &AuditData{ProcessTime: 279}
+	assert.Equal(t, 279*time.Millisecond, audit.SafeProcessTime())
+}
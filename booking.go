@@ -5,17 +5,27 @@ package hotelbeds
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/0x9ef/clientx"
+	"github.com/shopspring/decimal"
 )
 
+// BookingClient is the client of Booking API endpoints. Helpers built on top of the endpoints
+// (e.g. CountBookings) are methods of API, so implementations of the interface needn't provide them.
 type BookingClient interface {
 	ListAvailableHotels(ctx context.Context, inp *ListAvailableHotelsInput) (*ListAvailableHotelsResponse, error)
 	ListCheckRates(ctx context.Context, inp *ListCheckRatesInput) (*ListCheckRatesResponse, error)
 	GetBooking(ctx context.Context, id string) (*GetBookingResponse, error)
-	ListBookings(ctx context.Context, inp *CancelBookingInput) (*CancelBookingResponse, error)
+	ListBookings(ctx context.Context, inp *ListBookingsInput) (*ListBookingsResponse, error)
 	ConfirmBooking(ctx context.Context, inp *ConfirmBookingInput) (*ConfirmBookingResponse, error)
 	ChangeBooking(ctx context.Context, id string, inp *ChangeBookingInput) (*ChangeBookingResponse, error)
 	CancelBooking(ctx context.Context, id string, inp *CancelBookingInput) (*CancelBookingResponse, error)
@@ -29,8 +39,10 @@ type (
 		Geolocation *Geolocation  `json:"geolocation,omitempty"`
 		Filter      *Filter       `json:"filter,omitempty"`
 		Boards      *FilterBoards `json:"boards,omitempty"`
-		Rooms       *FilterRooms  `json:"rooms,omitempty"`
-		Hotels      FilterHotel   `json:"hotels"`
+		// Filter by board group codes (see ListBoardGroups), e.g. all "all inclusive" boards.
+		BoardGroups *FilterBoardGroups `json:"boardGroups,omitempty"`
+		Rooms       *FilterRooms       `json:"rooms,omitempty"`
+		Hotels      FilterHotel        `json:"hotels"`
 		// Displays price breakdown per each day of the hotel stay.
 		WithDailyRate bool `json:"dailyRate"`
 		// Hotelbeds Group client source market.
@@ -38,10 +50,19 @@ type (
 		// Defines the platform for multiclient developer platforms.
 		Platform int `json:"platform,omitempty"`
 		// Language code that defines the language of the response.
-		// English will be used by default if this field is not informed.
+		// Language set by WithLanguage or English will be used by default if this field is not informed.
 		Language string `json:"language,omitempty"`
-		// Filter for accomodation type.
-		Accomodations []string `json:"accomodations,omitempty"`
+		// Filter for accommodation type codes (see ListAccommodations).
+		Accommodations []string `json:"accommodations,omitempty"`
+		// Range of hotels to be returned when results are paginated, see ListAvailableHotelsResponse.NextPage.
+		From int `json:"from,omitempty"`
+		To   int `json:"to,omitempty"`
+		// Validate that every pax in Occupancies has Name and Surname
+		// (some contracts require named availability). Isn't sent to the API.
+		RequirePaxNames bool `json:"-"`
+		// Extra fields are added to the request body as is, e.g. parameters which aren't
+		// supported by the library yet. They aren't validated and can't override other fields.
+		Extra map[string]any `json:"-"`
 	}
 
 	AvailableHotel struct {
@@ -65,16 +86,19 @@ type (
 		Code  string `json:"code"`
 		Name  string `json:"name"`
 		Rates []Rate `json:"rates"`
+		// Upselling options of the room, if returned.
+		Upselling []UpsellingRate `json:"upselling,omitempty"`
 	}
 
 	Rate struct {
 		RateKey              string               `json:"rateKey"`
-		RateClass            string               `json:"rateClass"`
-		RateType             string               `json:"rateType"`
+		RateClass            RateClass            `json:"rateClass"`
+		RateType             RateType             `json:"rateType"`
 		Net                  Amount               `json:"net"`
 		Selling              Amount               `json:"sellingRate"`
 		Allotment            int                  `json:"allotment"`
 		RateCommentdsID      string               `json:"rateCommentsId,omitempty"`
+		RateComments         string               `json:"rateComments,omitempty"`
 		PaymentType          PaymentType          `json:"paymentType"`
 		Packaging            bool                 `json:"packaging"`
 		BoardCode            string               `json:"boardCode"`
@@ -84,22 +108,30 @@ type (
 		Adults               int                  `json:"adults"`
 		Children             int                  `json:"children"`
 		Offers               []Offer              `json:"offers,omitempty"`
+		BreakDown            *BreakDown           `json:"rateBreakDown,omitempty"`
+		// Rates for the alternative dates, returned when Stay.ShiftDays is specified.
+		ShiftRates []ShiftRate `json:"shiftRates,omitempty"`
+		// Breakdown of the price per night, returned when ListAvailableHotelsInput.WithDailyRate is set.
+		DailyRates []DailyRate `json:"dailyRates,omitempty"`
 	}
 
 	ShiftRate struct {
-		RateKey   string   `json:"rateKey"`
-		RateClass string   `json:"rateClass"`
-		RateType  string   `json:"rateType"`
-		Net       Amount   `json:"net"`
-		Selling   Amount   `json:"sellingRate"`
-		Allotment int      `json:"allotment"`
-		CheckIn   Datetime `json:"checkIn"`
-		CheckOut  Datetime `json:"checkOut"`
+		RateKey   string    `json:"rateKey"`
+		RateClass RateClass `json:"rateClass"`
+		RateType  RateType  `json:"rateType"`
+		Net       Amount    `json:"net"`
+		Selling   Amount    `json:"sellingRate"`
+		Allotment int       `json:"allotment"`
+		CheckIn   Datetime  `json:"checkIn"`
+		CheckOut  Datetime  `json:"checkOut"`
 	}
 
 	CancellationPolicy struct {
 		Amount Amount      `json:"amount"`
 		From   TimestampTZ `json:"from"`
+		// HoursBefore is the penalty cutoff as hours before the check-in,
+		// some policies report it instead of From. See CancellationPolicy.Cutoff.
+		HoursBefore *int `json:"hoursBefore,omitempty"`
 	}
 
 	Offer struct {
@@ -111,10 +143,13 @@ type (
 	ListAvailableHotelsResponse struct {
 		Audit  *AuditData `json:"auditData"`
 		Hotels struct {
-			CheckIn  Datetime         `json:"checkIn"`
-			CheckOut Datetime         `json:"checkOut"`
-			Total    int              `json:"total"`
-			Hotels   []AvailableHotel `json:"hotels"`
+			CheckIn  Datetime                   `json:"checkIn"`
+			CheckOut Datetime                   `json:"checkOut"`
+			Total    int                        `json:"total"`
+			Hotels   LooseSlice[AvailableHotel] `json:"hotels"`
+			// From and To are the range of returned hotels, reported only if results are paginated.
+			From int `json:"from,omitempty"`
+			To   int `json:"to,omitempty"`
 		} `json:"hotels"`
 	}
 
@@ -124,11 +159,18 @@ type (
 		Upselling bool `json:"upselling"`
 		// When true, it will add either the percent or the numberOfnights to the cancellation policies.
 		ExpandCXL bool `json:"expandCXL"`
+		// When true, rate comments are returned as a list of dated comments in RateCommentsDetails.
+		ExpandRateComments bool `json:"expandRateComments,omitempty"`
+		// When true, board details are returned in Board of every rate.
+		ExpandBoard bool `json:"expandBoard,omitempty"`
 		// Language code that defines the language of the response.
 		// English will be used by default if this field is not informed.
 		Language string `json:"language"`
 		// List of rooms to be checked/valuated.
 		Rooms []ListCheckRatesRoom `json:"rooms"`
+		// Extra fields are added to the request body as is, e.g. parameters which aren't
+		// supported by the library yet. They aren't validated and can't override other fields.
+		Extra map[string]any `json:"-"`
 	}
 
 	ListCheckRatesRoom struct {
@@ -170,10 +212,13 @@ type (
 	CheckRate struct {
 		Rate
 		RateComments string `json:"rateComments"`
-		Taxes        *struct {
+		// RateCommentsDetails is returned only if ListCheckRatesInput.ExpandRateComments is set.
+		RateCommentsDetails []RateCommentComment `json:"rateCommentsDetails,omitempty"`
+		// Board is returned only if ListCheckRatesInput.ExpandBoard is set.
+		Board *Board `json:"board,omitempty"`
+		Taxes *struct {
 			Taxes []Tax `json:"taxes"`
 		} `json:"taxes,omitempty"`
-		BreakDown *BreakDown `json:"breakDown,omitempty"`
 	}
 
 	Tax struct {
@@ -189,8 +234,10 @@ type (
 	}
 
 	Discount struct {
-		Code   string `json:"code"`
-		Name   string `json:"name"`
+		Code string `json:"code"`
+		Name string `json:"name"`
+		// The API usually sends discounts as negative amounts, but some
+		// suppliers send them as positive figures. See BreakDown.TotalDiscount.
 		Amount Amount `json:"amount"`
 	}
 
@@ -210,8 +257,8 @@ type (
 		Payment *PaymentData `json:"paymentData,omitempty"`
 		// Internal booking reference.
 		ClientReference string `json:"clientReference"`
-		// Identifies the agent name of the booking.
-		// If empty, by default it will be the same than the apikey performing the booking .
+		// Identifies the agent name of the booking. If empty, agent name set by WithDefaultCreationUser
+		// is used, otherwise by default it will be the same than the apikey performing the booking.
 		CreationUser string `json:"creationUser,omitempty"`
 		// Free text sent to the hotelier. It can be used to request or inform of special requests to hotelier like:
 		// “Non-smoking room preferred”, “Twin bed please”, “Upper floor preferred”, “Late arrival”….
@@ -227,16 +274,29 @@ type (
 		// English will be used by default if this field is not informed.
 		Language string               `json:"language,omitempty"`
 		Rooms    []ConfirmBookingRoom `json:"rooms"`
+		// Validate that Payment is set, should be copied from CheckRateHotel.PaymentDataRequired
+		// of the checked rates. Isn't sent to the API.
+		RequirePaymentData bool `json:"-"`
+		// Extra fields are added to the request body as is, e.g. parameters which aren't
+		// supported by the library yet. They aren't validated and can't override other fields.
+		Extra map[string]any `json:"-"`
 	}
 
 	ConfirmBookingRoom struct {
 		RateKey string `json:"rateKey"`
 		Paxes   []Pax  `json:"paxes"`
+		// Packaging rates can't be booked standalone, should be copied from Rate.Packaging
+		// of the booked rate (see Rate.RequiresPackage). Isn't sent to the API.
+		Packaging bool `json:"-"`
 	}
 
 	Holder struct {
 		Name    string `json:"name"`
 		Surname string `json:"surname"`
+		// Optional contact details of the holder used for confirmations.
+		Title       string `json:"title,omitempty"`
+		Email       string `json:"email,omitempty"`
+		PhoneNumber string `json:"phoneNumber,omitempty"`
 	}
 
 	PaymentData struct {
@@ -360,12 +420,35 @@ type (
 		SupplierReference string        `json:"supplierReference,omitempty"`
 		Paxes             []Pax         `json:"paxes"`
 		Rates             []Rate        `json:"rates"`
+		// Modification rules of the specific room, if returned.
+		ModificationPolicy *ModificationPolicy `json:"modificationPolicies,omitempty"`
+	}
+
+	// BookingSummary is the short presentation of the booking, e.g. for confirmation emails.
+	BookingSummary struct {
+		Reference string
+		HotelName string
+		CheckIn   Datetime
+		CheckOut  Datetime
+		Nights    int
+		Total     Amount
+		Currency  string
+		Rooms     int
+	}
+
+	// BillingInfo is the supplier and the invoicing company of the booking, e.g. for invoices.
+	BillingInfo struct {
+		SupplierName              string
+		SupplierVATNumber         string
+		InvoiceCompanyCode        string
+		InvoiceCompanyName        string
+		InvoiceRegistrationNumber string
 	}
 
 	InvoiceCompany struct {
 		RegistrationNumber string `json:"registrationNumber"`
 		Code               string `json:"code"`
-		Name               string `json:"name"`
+		Name               string `json:"company"`
 	}
 
 	Supplier struct {
@@ -375,16 +458,16 @@ type (
 
 	UpsellingRate struct {
 		Rate
-		Discount         string      `json:"discount"`
-		DiscountPercent  string      `json:"discountPCT"`
-		HotelMandatory   bool        `json:"hotelMandatory"`
-		Comission        Amount      `json:"comission"`
-		ComissionVAT     string      `json:"comissionVAT"`
-		ComissionPercent string      `json:"comissionPCT"`
-		Rateup           Amount      `json:"rateup"`
-		Brand            string      `json:"brand"`
-		Taxes            []Tax       `json:"taxes"`
-		DailyRates       []DailyRate `json:"dailyRates"`
+		// Numeric fields are sent either as JSON numbers or strings.
+		Discount         Amount    `json:"discount"`
+		DiscountPercent  FloatRate `json:"discountPCT"`
+		HotelMandatory   bool      `json:"hotelMandatory"`
+		Comission        Amount    `json:"comission"`
+		ComissionVAT     Amount    `json:"comissionVAT"`
+		ComissionPercent FloatRate `json:"comissionPCT"`
+		Rateup           Amount    `json:"rateup"`
+		Brand            string    `json:"brand"`
+		Taxes            []Tax     `json:"taxes"`
 	}
 
 	DailyRate struct {
@@ -417,7 +500,13 @@ type (
 
 	ListBookingsResponse struct {
 		Audit    *AuditData `json:"auditData"`
-		Bookings []Booking  `json:"bookings"`
+		Bookings struct {
+			From  int `json:"from"`
+			To    int `json:"to"`
+			Total int `json:"total"`
+			// Bookings contains only the requested page (from-to) of bookings.
+			Bookings []Booking `json:"bookings"`
+		} `json:"bookings"`
 	}
 
 	GetBookingResponse struct {
@@ -457,12 +546,89 @@ type BookingStatus string
 const (
 	BookingStatusConfirmed BookingStatus = "CONFIRMED"
 	BookingStatusCancelled BookingStatus = "CANCELLED"
+	BookingStatusPending   BookingStatus = "PENDING"
 )
 
 func (s BookingStatus) String() string {
 	return string(s)
 }
 
+// Valid reports whether booking status is one of the known statuses.
+func (s BookingStatus) Valid() bool {
+	switch s {
+	case BookingStatusConfirmed, BookingStatusCancelled, BookingStatusPending:
+		return true
+	}
+	return false
+}
+
+// IsTerminal reports whether booking status won't change without further actions.
+func (s BookingStatus) IsTerminal() bool {
+	return s == BookingStatusConfirmed || s == BookingStatusCancelled
+}
+
+// RateClass is the class of the rate, decoded case-insensitively.
+type RateClass string
+
+const (
+	RateClassNormal               RateClass = "NOR"
+	RateClassNonRefundable        RateClass = "NRF"
+	RateClassSpecial              RateClass = "SPE"
+	RateClassOffer                RateClass = "OFE"
+	RateClassPackage              RateClass = "PAQ"
+	RateClassNonRefundablePackage RateClass = "NRP"
+)
+
+func (c RateClass) String() string {
+	return string(c)
+}
+
+// Valid reports whether rate class is one of the known classes.
+func (c RateClass) Valid() bool {
+	switch c {
+	case RateClassNormal, RateClassNonRefundable, RateClassSpecial,
+		RateClassOffer, RateClassPackage, RateClassNonRefundablePackage:
+		return true
+	}
+	return false
+}
+
+func (c *RateClass) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("failed to parse RateClass: %w", err)
+	}
+	*c = RateClass(strings.ToUpper(strings.TrimSpace(s)))
+	return nil
+}
+
+// RateType defines whether the rate can be booked as is or must be checked with ListCheckRates first.
+// It's decoded case-insensitively.
+type RateType string
+
+const (
+	RateTypeBookable RateType = "BOOKABLE"
+	RateTypeRecheck  RateType = "RECHECK"
+)
+
+func (t RateType) String() string {
+	return string(t)
+}
+
+// Valid reports whether rate type is one of the known types.
+func (t RateType) Valid() bool {
+	return t == RateTypeBookable || t == RateTypeRecheck
+}
+
+func (t *RateType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("failed to parse RateType: %w", err)
+	}
+	*t = RateType(strings.ToUpper(strings.TrimSpace(s)))
+	return nil
+}
+
 type Mode string
 
 const (
@@ -475,21 +641,382 @@ func (m Mode) String() string {
 	return string(m)
 }
 
-func (inp *ListAvailableHotelsInput) Validate() error {
-	if err := inp.Stay.Validate(); err != nil {
-		return err
+// Valid reports whether mode is one of the known modes.
+func (m Mode) Valid() bool {
+	switch m {
+	case ModeUpdate, ModeCancellation, ModeSimulation:
+		return true
 	}
-	if inp.Filter != nil {
-		if err := inp.Filter.Validate(); err != nil {
-			return err
+	return false
+}
+
+// validateMode checks that mode is valid and is one of allowed modes of the operation.
+func validateMode(mode Mode, required bool, allowed ...Mode) error {
+	if mode == "" && !required {
+		return nil
+	}
+	if mode.Valid() {
+		for _, m := range allowed {
+			if mode == m {
+				return nil
+			}
 		}
 	}
-	if err := inp.Hotels.Validate(); err != nil {
+	allow := make([]string, len(allowed))
+	for i := range allowed {
+		allow[i] = allowed[i].String()
+	}
+	return &ValidationError{
+		FieldName: "Mode",
+		Required:  mode == "",
+		Allow:     allow,
+	}
+}
+
+func (inp *ChangeBookingInput) Validate() error {
+	var errs ValidationErrors
+	errs.add(validateMode(inp.Mode, true, ModeSimulation, ModeUpdate))
+	if inp.Booking == nil {
+		errs.add(&ValidationError{
+			FieldName: "Booking",
+			Required:  true,
+		})
+	}
+	return errs.err()
+}
+
+// Validate checks Mode, which is optional, as the API defaults it.
+func (inp *CancelBookingInput) Validate() error {
+	return validateMode(inp.Mode, false, ModeCancellation, ModeSimulation)
+}
+
+func (inp ListBookingsInput) Encode(v url.Values) error {
+	if inp.FilterType != "" {
+		v.Set("filterType", inp.FilterType)
+	}
+	if inp.FilterClientReference != "" {
+		v.Set("clientReference", inp.FilterClientReference)
+	}
+	if inp.FilterCreationUser != "" {
+		v.Set("creationUser", inp.FilterCreationUser)
+	}
+	if len(inp.FilterCountires) != 0 {
+		v.Set("country", strings.Join(inp.FilterCountires, ","))
+	}
+	if len(inp.FilterDestinations) != 0 {
+		v.Set("destination", strings.Join(inp.FilterDestinations, ","))
+	}
+	if len(inp.FilterHotels) != 0 {
+		v.Set("hotel", joinInts[int](inp.FilterHotels))
+	}
+	if !inp.FilterStart.IsZero() {
+		v.Set("start", inp.FilterStart.String())
+	}
+	if !inp.FilterEnd.IsZero() {
+		v.Set("end", inp.FilterEnd.String())
+	}
+	if inp.Language != "" {
+		v.Set("language", inp.Language)
+	}
+	if inp.From != 0 {
+		v.Set("from", strconv.Itoa(inp.From))
+	}
+	if inp.To != 0 {
+		v.Set("to", strconv.Itoa(inp.To))
+	}
+	return nil
+}
+
+func (inp ListAvailableHotelsInput) MarshalJSON() ([]byte, error) {
+	type input ListAvailableHotelsInput
+	return marshalWithExtra(input(inp), inp.Extra)
+}
+
+func (inp ListCheckRatesInput) MarshalJSON() ([]byte, error) {
+	type input ListCheckRatesInput
+	return marshalWithExtra(input(inp), inp.Extra)
+}
+
+func (inp ConfirmBookingInput) MarshalJSON() ([]byte, error) {
+	type input ConfirmBookingInput
+	return marshalWithExtra(input(inp), inp.Extra)
+}
+
+// maxClientReferenceLength is the maximal length of ConfirmBookingInput.ClientReference.
+const maxClientReferenceLength = 20
+
+// maxBookingRooms is the maximal number of rooms of a single booking. It's the same
+// as the limit of rooms of an occupancy of the availability request (maxOccupancyRooms),
+// accounts with a lower limit may pass it to SplitBookingByRoomLimit.
+const maxBookingRooms = maxOccupancyRooms
+
+// SplitBookingByRoomLimit splits the input with more than maxRooms rooms (maxBookingRooms if
+// it's not positive) into multiple inputs, each confirmed as a separate booking. Other fields
+// (holder, payment data, etc.) are copied as is, except ClientReference, which is suffixed
+// with the number of the part (e.g. "AgencyRef-2") to keep references distinct. Suffixed
+// reference must fit the limit of ClientReference. Input within the limit is returned as is.
+func SplitBookingByRoomLimit(inp *ConfirmBookingInput, maxRooms int) ([]*ConfirmBookingInput, error) {
+	if maxRooms <= 0 || maxRooms > maxBookingRooms {
+		maxRooms = maxBookingRooms
+	}
+	if len(inp.Rooms) <= maxRooms {
+		return []*ConfirmBookingInput{inp}, nil
+	}
+	parts := (len(inp.Rooms) + maxRooms - 1) / maxRooms
+	if suffix := "-" + strconv.Itoa(parts); len(inp.ClientReference)+len(suffix) > maxClientReferenceLength {
+		return nil, ValidationErrors{{
+			FieldName: "ClientReference",
+			Required:  true,
+			Max:       maxClientReferenceLength - len(suffix),
+		}}
+	}
+	inps := make([]*ConfirmBookingInput, 0, parts)
+	for start := 0; start < len(inp.Rooms); start += maxRooms {
+		end := start + maxRooms
+		if end > len(inp.Rooms) {
+			end = len(inp.Rooms)
+		}
+		part := *inp
+		part.ClientReference = inp.ClientReference + "-" + strconv.Itoa(len(inps)+1)
+		part.Rooms = inp.Rooms[start:end:end]
+		inps = append(inps, &part)
+	}
+	return inps, nil
+}
+
+// NewConfirmBookingRoom returns room of ConfirmBookingInput which books the checked rate for paxes.
+// Paxes must match occupancy of the rate, i.e. adults and children of each of its rooms.
+func NewConfirmBookingRoom(cr CheckRate, paxes []Pax) (ConfirmBookingRoom, error) {
+	rooms := cr.Rooms
+	if rooms < 1 {
+		rooms = 1
+	}
+	var adults, children int
+	for _, pax := range paxes {
+		switch pax.Type {
+		case PaxTypeAdult:
+			adults++
+		case PaxTypeChildren:
+			children++
+		}
+	}
+
+	var errs ValidationErrors
+	if cr.RateKey == "" {
+		errs.add(&ValidationError{
+			FieldName: "RateKey",
+			Required:  true,
+		})
+	}
+	if want := cr.Adults * rooms; adults != want {
+		errs.add(&ValidationError{
+			FieldName: "Paxes.Adults",
+			Min:       want,
+			Max:       want,
+		})
+	}
+	if want := cr.Children * rooms; children != want {
+		errs.add(&ValidationError{
+			FieldName: "Paxes.Children",
+			Min:       want,
+			Max:       want,
+		})
+	}
+	if err := errs.err(); err != nil {
+		return ConfirmBookingRoom{}, err
+	}
+	return ConfirmBookingRoom{
+		RateKey:   cr.RateKey,
+		Paxes:     paxes,
+		Packaging: cr.RequiresPackage(),
+	}, nil
+}
+
+// ValidateRateKey checks that the rateKey is issued for the expected hotel and stay dates,
+// so rates of another hotel or search aren't confirmed by mistake. The error wraps
+// ErrInvalidRateKey if the rateKey can't be parsed and ErrRateKeyMismatch otherwise.
+func (api *API) ValidateRateKey(rateKey string, expectHotel int, stay Stay) error {
+	info, err := ParseRateKey(rateKey)
+	if err != nil {
 		return err
 	}
+	if info.HotelCode != expectHotel {
+		return fmt.Errorf("%w: hotel code %d, expected %d", ErrRateKeyMismatch, info.HotelCode, expectHotel)
+	}
+	if checkIn := info.CheckIn.String(); checkIn != stay.CheckIn {
+		return fmt.Errorf("%w: check-in %s, expected %s", ErrRateKeyMismatch, checkIn, stay.CheckIn)
+	}
+	if checkOut := info.CheckOut.String(); checkOut != stay.CheckOut {
+		return fmt.Errorf("%w: check-out %s, expected %s", ErrRateKeyMismatch, checkOut, stay.CheckOut)
+	}
 	return nil
 }
 
+// Validate reports all invalid fields of the input at once.
+func (inp *ConfirmBookingInput) Validate() error {
+	var errs ValidationErrors
+	if inp.Holder.Name == "" {
+		errs.add(&ValidationError{
+			FieldName: "Holder.Name",
+			Required:  true,
+		})
+	}
+	if inp.Holder.Surname == "" {
+		errs.add(&ValidationError{
+			FieldName: "Holder.Surname",
+			Required:  true,
+		})
+	}
+	if inp.ClientReference == "" || len(inp.ClientReference) > maxClientReferenceLength {
+		errs.add(&ValidationError{
+			FieldName: "ClientReference",
+			Required:  true,
+			Max:       maxClientReferenceLength,
+		})
+	}
+	if len(inp.Rooms) == 0 || len(inp.Rooms) > maxBookingRooms {
+		errs.add(&ValidationError{
+			FieldName: "Rooms",
+			Required:  true,
+			Max:       maxBookingRooms,
+		})
+	}
+	for _, room := range inp.Rooms {
+		if room.RateKey == "" {
+			errs.add(&ValidationError{
+				FieldName: "Rooms.RateKey",
+				Required:  true,
+			})
+		}
+		if room.Packaging {
+			// Standalone confirm of the packaging rate fails, it's bookable only as part of a package.
+			errs.add(&ValidationError{
+				FieldName: "Rooms.Packaging",
+				Allow:     []string{"false"},
+			})
+		}
+	}
+	if inp.RequirePaymentData && inp.Payment == nil {
+		errs.add(&ValidationError{
+			FieldName: "Payment",
+			Required:  true,
+		})
+	}
+	if inp.hasPaxes() {
+		for _, room := range inp.Rooms {
+			errs.add(room.validatePaxRooms())
+		}
+	}
+	return errs.err()
+}
+
+// hasPaxes reports whether paxes are specified for any room of the input. Assignment of paxes
+// to rooms is validated only if they're specified, otherwise the API assigns them itself.
+func (inp *ConfirmBookingInput) hasPaxes() bool {
+	for _, room := range inp.Rooms {
+		if len(room.Paxes) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePaxRooms checks that every room booked by the rate (see RateKeyInfo.Rooms) has
+// at least one adult pax and no pax references a non-existent room. Pax without RoomID
+// is assigned to the first room.
+func (room ConfirmBookingRoom) validatePaxRooms() error {
+	rooms := 1
+	if info, err := ParseRateKey(room.RateKey); err == nil && info.Rooms > 1 {
+		rooms = info.Rooms
+	}
+
+	var errs ValidationErrors
+	adults := make([]int, rooms+1)
+	for _, pax := range room.Paxes {
+		roomID := pax.RoomID
+		if roomID == 0 {
+			roomID = 1
+		}
+		if roomID < 1 || roomID > rooms {
+			errs.add(&ValidationError{
+				FieldName: "Rooms.Paxes.RoomID",
+				Min:       1,
+				Max:       rooms,
+			})
+			continue
+		}
+		if pax.Type == PaxTypeAdult {
+			adults[roomID]++
+		}
+	}
+	for roomID := 1; roomID <= rooms; roomID++ {
+		if adults[roomID] == 0 {
+			errs.add(&ValidationError{
+				FieldName: "Rooms.Paxes.Adult",
+				Required:  true,
+			})
+		}
+	}
+	return errs.err()
+}
+
+// Validate reports all invalid fields of the input and its nested filters at once.
+func (inp *ListAvailableHotelsInput) Validate() error {
+	var errs ValidationErrors
+	errs.add(inp.Stay.Validate())
+	if len(inp.Occupancies) == 0 {
+		errs.add(&ValidationError{
+			FieldName: "Occupancies",
+			Required:  true,
+		})
+	}
+	for i := range inp.Keywords {
+		errs.add(inp.Keywords[i].Validate())
+	}
+	if inp.Geolocation != nil {
+		errs.add(inp.Geolocation.Validate())
+	}
+	if inp.Filter != nil {
+		errs.add(inp.Filter.Validate())
+		// Results are silently incomplete if fewer rooms are returned than requested.
+		if rooms, _, _ := inp.TotalOccupancy(); inp.Filter.MaxRooms >= 1 && inp.Filter.MaxRooms <= 50 && inp.Filter.MaxRooms < rooms {
+			errs.add(&ValidationError{
+				FieldName: "MaxRooms",
+				Min:       rooms,
+				Max:       50,
+			})
+		}
+	}
+	for _, code := range inp.Accommodations {
+		if code == "" {
+			errs.add(&ValidationError{
+				FieldName: "Accommodations",
+				Required:  true,
+			})
+			break
+		}
+	}
+	if inp.Boards != nil {
+		errs.add(inp.Boards.Validate())
+	}
+	if inp.Rooms != nil {
+		errs.add(inp.Rooms.Validate())
+	}
+	if inp.BoardGroups != nil {
+		errs.add(inp.BoardGroups.Validate())
+	}
+	errs.add(inp.Hotels.Validate())
+	for i := range inp.Occupancies {
+		errs.add(inp.Occupancies[i].validate())
+	}
+	if inp.RequirePaxNames {
+		for i := range inp.Occupancies {
+			errs.add(inp.Occupancies[i].validatePaxNames())
+		}
+	}
+	return errs.err()
+}
+
 type Stay struct {
 	CheckIn  string `json:"checkIn"`
 	CheckOut string `json:"checkOut"`
@@ -501,7 +1028,10 @@ type Stay struct {
 
 func (stay *Stay) Validate() error {
 	if stay.ShiftDays > 5 {
-		return errors.New("ShiftDays is invalid (should <=5)")
+		return &ValidationError{
+			FieldName: "ShiftDays",
+			Max:       5,
+		}
 	}
 	return nil
 }
@@ -514,6 +1044,80 @@ type Occupancy struct {
 	Paxes []Pax `json:"paxes,omitempty"`
 }
 
+// TotalOccupancy returns the number of rooms, adults and children requested by all occupancies.
+// Adults and children of the occupancy are per room, so they're multiplied by its rooms.
+func (inp ListAvailableHotelsInput) TotalOccupancy() (rooms, adults, children int) {
+	for _, occ := range inp.Occupancies {
+		rooms += occ.Rooms
+		adults += occ.Rooms * occ.Adults
+		children += occ.Rooms * occ.Children
+	}
+	return rooms, adults, children
+}
+
+// StaysFor returns stays of nights with check-in on every date from start up to, but
+// not including, end (see DateRange), e.g. to search availability for a price calendar.
+func StaysFor(start, end Datetime, nights int) []Stay {
+	if nights < 1 {
+		return nil
+	}
+	var stays []Stay
+	for checkIn := time.Time(start); checkIn.Before(time.Time(end)); checkIn = checkIn.AddDate(0, 0, 1) {
+		stays = append(stays, Stay{
+			CheckIn:  Datetime(checkIn).String(),
+			CheckOut: Datetime(checkIn.AddDate(0, 0, nights)).String(),
+		})
+	}
+	return stays
+}
+
+// maxOccupancyRooms is the maximal number of rooms of a single occupancy.
+const maxOccupancyRooms = 9
+
+// SameOccupancyRooms returns occupancies of count identical rooms, each of them
+// accommodating adults and children of childAges.
+func SameOccupancyRooms(count, adults int, childAges ...int) []Occupancy {
+	occ := Occupancy{
+		Rooms:    count,
+		Adults:   adults,
+		Children: len(childAges),
+	}
+	for _, age := range childAges {
+		occ.Paxes = append(occ.Paxes, Pax{Type: PaxTypeChildren, Age: age})
+	}
+	return []Occupancy{occ}
+}
+
+func (occ *Occupancy) validate() error {
+	if occ.Rooms < 1 || occ.Rooms > maxOccupancyRooms {
+		return &ValidationError{
+			FieldName: "Occupancy.Rooms",
+			Min:       1,
+			Max:       maxOccupancyRooms,
+		}
+	}
+	return nil
+}
+
+func (occ *Occupancy) validatePaxNames() error {
+	var errs ValidationErrors
+	for _, pax := range occ.Paxes {
+		if pax.Name == "" {
+			errs.add(&ValidationError{
+				FieldName: "Occupancy.Paxes.Name",
+				Required:  true,
+			})
+		}
+		if pax.Surname == "" {
+			errs.add(&ValidationError{
+				FieldName: "Occupancy.Paxes.Surname",
+				Required:  true,
+			})
+		}
+	}
+	return errs.err()
+}
+
 type Pax struct {
 	Type    PaxType `json:"type"`
 	Age     int     `json:"age"`
@@ -538,40 +1142,61 @@ type Keyword struct {
 	AllIncluded bool  `json:"allIncluded"`
 }
 
+func (k *Keyword) Validate() error {
+	if len(k.Keywords) == 0 {
+		return &ValidationError{
+			FieldName: "Keyword.Keywords",
+			Required:  true,
+		}
+	}
+	return nil
+}
+
 type Geolocation struct {
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
-	Radius    Radius  `json:"radius"`
-	Unit      Unit    `json:"unit"`
+	// Latitude and Longitude are pointers, so 0.0 (equator, prime meridian) is a valid value.
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Radius    Radius   `json:"radius"`
+	// Unit of the Radius, kilometers are sent if empty.
+	Unit Unit `json:"unit"`
+}
+
+func (geo Geolocation) MarshalJSON() ([]byte, error) {
+	type geolocation Geolocation
+	if geo.Unit == "" {
+		geo.Unit = UnitKilometers
+	}
+	return json.Marshal(geolocation(geo))
 }
 
 func (geo *Geolocation) Validate() error {
-	if geo.Latitude == 0 {
-		return &ValidationError{
+	var errs ValidationErrors
+	if geo.Latitude == nil {
+		errs.add(&ValidationError{
 			FieldName: "Latitude",
 			Required:  true,
-		}
+		})
 	}
-	if geo.Longitude == 0 {
-		return &ValidationError{
+	if geo.Longitude == nil {
+		errs.add(&ValidationError{
 			FieldName: "Longitude",
 			Required:  true,
-		}
+		})
 	}
-	if geo.Radius > 200 {
-		return &ValidationError{
+	if geo.Radius < 1 || geo.Radius > 200 {
+		errs.add(&ValidationError{
 			FieldName: "Radius",
-			Min:       0,
+			Min:       1,
 			Max:       200,
-		}
+		})
 	}
 	if geo.Unit != "" && geo.Unit != UnitMiles && geo.Unit != UnitKilometers {
-		return &ValidationError{
+		errs.add(&ValidationError{
 			FieldName: "Unit",
 			Allow:     []string{UnitMiles.String(), UnitKilometers.String()},
-		}
+		})
 	}
-	return nil
+	return errs.err()
 }
 
 type Filter struct {
@@ -582,35 +1207,69 @@ type Filter struct {
 	MaxRatesPerRoom int       `json:"maxRatesPerRoom"`
 	MinCategory     int       `json:"minCategory,omitempty"`
 	MaxCategory     int       `json:"maxCategory,omitempty"`
+	// Segments filters hotels by market segment codes (e.g. business, leisure), see ListSegments.
+	Segments []int `json:"segments,omitempty"`
 }
 
 func (filter *Filter) Validate() error {
+	var errs ValidationErrors
 	if filter.MaxHotels < 1 || filter.MaxHotels > 2000 {
-		return &ValidationError{
+		errs.add(&ValidationError{
 			FieldName: "MaxHotels",
 			Min:       1,
 			Max:       2000,
-		}
+		})
 	}
 	if filter.MaxRooms < 1 || filter.MaxRooms > 50 {
-		return &ValidationError{
+		errs.add(&ValidationError{
 			FieldName: "MaxRooms",
 			Min:       1,
 			Max:       50,
-		}
+		})
 	}
 	if filter.MinCategory < 1 || filter.MinCategory > 5 {
-		return &ValidationError{
+		errs.add(&ValidationError{
 			FieldName: "MinCategory",
 			Min:       1,
 			Max:       5,
-		}
+		})
 	}
 	if filter.MaxCategory < 1 || filter.MaxCategory > 5 {
-		return &ValidationError{
+		errs.add(&ValidationError{
 			FieldName: "MaxCategory",
 			Min:       1,
 			Max:       5,
+		})
+	}
+	for _, code := range filter.Segments {
+		if code < 1 {
+			errs.add(&ValidationError{
+				FieldName: "Segments",
+				Min:       1,
+			})
+			break
+		}
+	}
+	return errs.err()
+}
+
+// ValidateSegments checks that segment codes of the filter are known,
+// segments are the reference data returned by ListSegments.
+func (filter *Filter) ValidateSegments(segments []Segment) error {
+	known := make(map[int]bool, len(segments))
+	for _, segment := range segments {
+		known[segment.Code] = true
+	}
+	for _, code := range filter.Segments {
+		if !known[code] {
+			allow := make([]string, len(segments))
+			for i, segment := range segments {
+				allow[i] = strconv.Itoa(segment.Code)
+			}
+			return &ValidationError{
+				FieldName: "Segments",
+				Allow:     allow,
+			}
 		}
 	}
 	return nil
@@ -621,9 +1280,49 @@ type FilterBoards struct {
 	Included bool     `json:"included"`
 }
 
+func (f *FilterBoards) Validate() error {
+	return validateCodes("FilterBoards.Boards", f.Boards)
+}
+
+// FilterBoardGroups filters availability by board group codes. The API expects codes in "boardGroup" array.
+type FilterBoardGroups struct {
+	Codes []string `json:"boardGroup"`
+	// When true only boards of the groups are returned, otherwise boards of the groups are excluded.
+	Included bool `json:"included"`
+}
+
+func (f *FilterBoardGroups) Validate() error {
+	return validateCodes("FilterBoardGroups.BoardGroup", f.Codes)
+}
+
+// FilterRooms filters availability by room codes. The API expects codes in "room" array.
 type FilterRooms struct {
-	Codes    []string `json:"room"`
-	Included bool     `json:"included"`
+	Codes []string `json:"room"`
+	// When true only rooms with Codes are returned, otherwise rooms with Codes are excluded.
+	Included bool `json:"included"`
+}
+
+func (f *FilterRooms) Validate() error {
+	return validateCodes("FilterRooms.Room", f.Codes)
+}
+
+// validateCodes checks that codes of the filter are specified and none of them is empty.
+func validateCodes(fieldName string, codes []string) error {
+	if len(codes) == 0 {
+		return &ValidationError{
+			FieldName: fieldName,
+			Required:  true,
+		}
+	}
+	for _, code := range codes {
+		if strings.TrimSpace(code) == "" {
+			return &ValidationError{
+				FieldName: fieldName,
+				Required:  true,
+			}
+		}
+	}
+	return nil
 }
 
 type FilterHotel struct {
@@ -651,77 +1350,994 @@ func (p PaymentType) String() string {
 	return string(p)
 }
 
+// Valid reports whether payment type is one of the known types.
+func (p PaymentType) Valid() bool {
+	return p == PaymentTypeAtWeb || p == PaymentTypeAtHotel
+}
+
+// SupplierReferences returns distinct non-empty supplier references of all booking rooms.
+func (b *Booking) SupplierReferences() []string {
+	var refs []string
+	seen := make(map[string]bool, len(b.Hotel.Rooms))
+	for _, room := range b.Hotel.Rooms {
+		if room.SupplierReference == "" || seen[room.SupplierReference] {
+			continue
+		}
+		seen[room.SupplierReference] = true
+		refs = append(refs, room.SupplierReference)
+	}
+	return refs
+}
+
+// TotalDiscount returns the sum of all rate discounts. Discounts always reduce the price,
+// so the result is negative (or zero) regardless of the sign convention used by the API.
+func (b BreakDown) TotalDiscount() Amount {
+	var total decimal.Decimal
+	for _, discount := range b.Discounts {
+		total = total.Sub(decimal.Decimal(discount.Amount).Abs())
+	}
+	return Amount(total)
+}
+
+// RequiresPackage reports whether the rate must be booked as part of a package
+// (along with other travel services), so it can't be confirmed standalone.
+func (r Rate) RequiresPackage() bool {
+	return r.Packaging
+}
+
+// NetAfterOffers returns net price of the rate reduced by the offers.
+// Offer amounts are treated as discounts regardless of their sign, same as BreakDown.TotalDiscount.
+func (r Rate) NetAfterOffers() Amount {
+	return Amount(decimal.Decimal(r.Net).Add(r.totalOffers()))
+}
+
+// SellingAfterOffers returns selling price of the rate reduced by the offers.
+// Zero is returned if the selling price isn't reported.
+func (r Rate) SellingAfterOffers() Amount {
+	if decimal.Decimal(r.Selling).IsZero() {
+		return r.Selling
+	}
+	return Amount(decimal.Decimal(r.Selling).Add(r.totalOffers()))
+}
+
+func (r Rate) totalOffers() decimal.Decimal {
+	var total decimal.Decimal
+	for _, offer := range r.Offers {
+		total = total.Sub(decimal.Decimal(offer.Amount).Abs())
+	}
+	return total
+}
+
+// TotalFromDailyRates returns sum of net amounts of the daily rates.
+func (r Rate) TotalFromDailyRates() Amount {
+	var total decimal.Decimal
+	for _, daily := range r.DailyRates {
+		total = total.Add(decimal.Decimal(daily.Net))
+	}
+	return Amount(total)
+}
+
+// AverageNightlyNet returns average net amount per night of the daily rates,
+// e.g. to display "from X/night". Zero is returned if daily rates aren't reported.
+func (r Rate) AverageNightlyNet() Amount {
+	if len(r.DailyRates) == 0 {
+		return Amount{}
+	}
+	total := decimal.Decimal(r.TotalFromDailyRates())
+	return Amount(total.Div(decimal.NewFromInt(int64(len(r.DailyRates)))))
+}
+
+// VerifyDailyRates checks that sum of the daily rates equals to Net of the rate.
+// Rates without daily rates are considered valid. The currency of the rate (e.g. currency
+// of the hotel) is used to format amounts of the error.
+func (r Rate) VerifyDailyRates(currency string) error {
+	if len(r.DailyRates) == 0 {
+		return nil
+	}
+	total, net := r.TotalFromDailyRates(), r.Net
+	if decimal.Decimal(total).Sub(decimal.Decimal(net)).Abs().GreaterThan(totalsEpsilon) {
+		return fmt.Errorf("net mismatch: reported %s, sum of daily rates %s", net.StringCurrency(currency), total.StringCurrency(currency))
+	}
+	return nil
+}
+
+// totalsEpsilon is the maximal accepted difference between reported totals and sum of rates.
+var totalsEpsilon = decimal.New(1, -2)
+
+// VerifyTotals checks that TotalNet and TotalSellingRate equal to the sum of
+// rooms rates Net and Selling respectively. TotalSellingRate is verified only if it's reported.
+func (h *BookingHotel) VerifyTotals() error {
+	var net, selling decimal.Decimal
+	for _, room := range h.Rooms {
+		for _, rate := range room.Rates {
+			net = net.Add(decimal.Decimal(rate.Net))
+			selling = selling.Add(decimal.Decimal(rate.Selling))
+		}
+	}
+	if totalNet := decimal.Decimal(h.TotalNet); totalNet.Sub(net).Abs().GreaterThan(totalsEpsilon) {
+		return fmt.Errorf("totalNet mismatch: reported %s, sum of rates %s",
+			h.TotalNet.StringCurrency(h.Currency), Amount(net).StringCurrency(h.Currency))
+	}
+	if totalSelling := decimal.Decimal(h.TotalSellingRate); !totalSelling.IsZero() && totalSelling.Sub(selling).Abs().GreaterThan(totalsEpsilon) {
+		return fmt.Errorf("totalSellingRate mismatch: reported %s, sum of rates %s",
+			h.TotalSellingRate.StringCurrency(h.Currency), Amount(selling).StringCurrency(h.Currency))
+	}
+	return nil
+}
+
+// CheapestRefundable returns the rate with the lowest net price that can be cancelled
+// free of charge until before, i.e. its earliest cancellation penalty starts after before.
+// Rates without cancellation policies are considered refundable.
+func (room AvailableHotelRoom) CheapestRefundable(before time.Time) (Rate, bool) {
+	var (
+		cheapest Rate
+		found    bool
+	)
+	for _, rate := range room.Rates {
+		if penalty, ok := rate.earliestPenalty(); ok && !penalty.After(before) {
+			continue
+		}
+		if !found || decimal.Decimal(rate.Net).LessThan(decimal.Decimal(cheapest.Net)) {
+			cheapest, found = rate, true
+		}
+	}
+	return cheapest, found
+}
+
+// rateDedupKey identifies rates with the same board and occupancy.
+type rateDedupKey struct {
+	boardCode               string
+	rooms, adults, children int
+}
+
+// CategoryContent returns CategoryName as Content to be used interchangeably with
+// Content API. LanguageCode is empty, as availability doesn't report it.
+func (hotel AvailableHotel) CategoryContent() Content {
+	return Content{Content: hotel.CategoryName}
+}
+
+// ZoneContent returns ZoneName as Content, see CategoryContent.
+func (hotel AvailableHotel) ZoneContent() Content {
+	return Content{Content: hotel.ZoneName}
+}
+
+// DedupRates returns copy of the room, where rates with the same board and occupancy
+// are collapsed into the one with the lowest net price. Order of rates is preserved.
+func (room AvailableHotelRoom) DedupRates() AvailableHotelRoom {
+	indexes := make(map[rateDedupKey]int, len(room.Rates))
+	rates := make([]Rate, 0, len(room.Rates))
+	for _, rate := range room.Rates {
+		key := rateDedupKey{boardCode: rate.BoardCode, rooms: rate.Rooms, adults: rate.Adults, children: rate.Children}
+		i, ok := indexes[key]
+		if !ok {
+			indexes[key] = len(rates)
+			rates = append(rates, rate)
+			continue
+		}
+		if decimal.Decimal(rate.Net).LessThan(decimal.Decimal(rates[i].Net)) {
+			rates[i] = rate
+		}
+	}
+	room.Rates = rates
+	return room
+}
+
+// EnrichDescriptions replaces names of the rooms and boards of rates (including upselling)
+// with descriptions of the reference data (see ListRooms and ListBoards) in lang. Reference
+// data in other language is ignored, unless lang is empty. Names of unknown codes are kept.
+func (h *AvailableHotel) EnrichDescriptions(boards []Board, rooms []Room, lang string) {
+	inLang := func(c Content) bool {
+		return lang == "" || c.LanguageCode == "" || strings.EqualFold(c.LanguageCode, lang)
+	}
+	boardNames := make(map[string]string, len(boards))
+	for _, board := range boards {
+		if board.Description.Content != "" && inLang(board.Description) {
+			boardNames[board.Code] = board.Description.Content
+		}
+	}
+	roomNames := make(map[string]string, len(rooms))
+	for _, room := range rooms {
+		if room.Description != "" && inLang(room.TypeDescription) {
+			roomNames[room.Code] = room.Description
+		}
+	}
+
+	for i := range h.Rooms {
+		room := &h.Rooms[i]
+		if name, ok := roomNames[room.Code]; ok {
+			room.Name = name
+		}
+		for j := range room.Rates {
+			if name, ok := boardNames[room.Rates[j].BoardCode]; ok {
+				room.Rates[j].BoardName = name
+			}
+		}
+		for j := range room.Upselling {
+			if name, ok := boardNames[room.Upselling[j].BoardCode]; ok {
+				room.Upselling[j].BoardName = name
+			}
+		}
+	}
+}
+
+// FilterByAllotment returns copy of the response with only rates which have at least min rooms
+// available. Rooms and hotels left without rates are removed, Total is kept as reported.
+func (resp *ListAvailableHotelsResponse) FilterByAllotment(min int) *ListAvailableHotelsResponse {
+	filtered := *resp
+	filtered.Hotels.Hotels = make([]AvailableHotel, 0, len(resp.Hotels.Hotels))
+	for _, hotel := range resp.Hotels.Hotels {
+		rooms := make([]AvailableHotelRoom, 0, len(hotel.Rooms))
+		for _, room := range hotel.Rooms {
+			rates := make([]Rate, 0, len(room.Rates))
+			for _, rate := range room.Rates {
+				if rate.Allotment >= min {
+					rates = append(rates, rate)
+				}
+			}
+			if len(rates) != 0 {
+				room.Rates = rates
+				rooms = append(rooms, room)
+			}
+		}
+		if len(rooms) != 0 {
+			hotel.Rooms = rooms
+			filtered.Hotels.Hotels = append(filtered.Hotels.Hotels, hotel)
+		}
+	}
+	return &filtered
+}
+
+// AvailabilitySummary aggregates rates of all hotels in availability response.
+type AvailabilitySummary struct {
+	TotalHotels int
+	// MinRate is the lowest minRate across all hotels.
+	MinRate Amount
+	// MaxRate is the highest maxRate across all hotels.
+	MaxRate Amount
+	// AverageRate is the average of hotels minRate.
+	AverageRate Amount
+	// Currency is the currency of rates, empty if hotels return mixed currencies.
+	Currency        string
+	MixedCurrencies bool
+}
+
+// Summary returns rates statistics across all hotels of the response.
+func (resp *ListAvailableHotelsResponse) Summary() AvailabilitySummary {
+	hotels := resp.Hotels.Hotels
+	summary := AvailabilitySummary{TotalHotels: len(hotels)}
+	if len(hotels) == 0 {
+		return summary
+	}
+
+	var minRate, maxRate, sum decimal.Decimal
+	for i, hotel := range hotels {
+		hotelMin := decimal.NewFromFloat(hotel.MinRate.Float())
+		hotelMax := decimal.NewFromFloat(hotel.MaxRate.Float())
+		if i == 0 || hotelMin.LessThan(minRate) {
+			minRate = hotelMin
+		}
+		if i == 0 || hotelMax.GreaterThan(maxRate) {
+			maxRate = hotelMax
+		}
+		sum = sum.Add(hotelMin)
+
+		if i == 0 {
+			summary.Currency = hotel.Currency
+		} else if hotel.Currency != summary.Currency {
+			summary.MixedCurrencies = true
+		}
+	}
+	if summary.MixedCurrencies {
+		summary.Currency = ""
+	}
+	summary.MinRate = Amount(minRate)
+	summary.MaxRate = Amount(maxRate)
+	summary.AverageRate = Amount(sum.DivRound(decimal.NewFromInt(int64(len(hotels))), 2))
+	return summary
+}
+
+// NextPage returns input to request the next page of paginated results, which
+// has the same size as the current page. False is returned if there are no more pages.
+func (resp *ListAvailableHotelsResponse) NextPage(inp *ListAvailableHotelsInput) (*ListAvailableHotelsInput, bool) {
+	from, to := resp.Hotels.From, resp.Hotels.To
+	if to == 0 || to < from || to >= resp.Hotels.Total {
+		return nil, false
+	}
+	next := *inp
+	next.From = to + 1
+	next.To = to + (to - from + 1)
+	return &next, true
+}
+
+// RateResult is a rate of the availability response along with its hotel and room.
+type RateResult struct {
+	HotelCode int
+	HotelName string
+	RoomCode  string
+	RoomName  string
+	Currency  string
+	Rate      Rate
+}
+
+// FlattenRates returns rates of all hotels and rooms of the response in the order they're returned.
+func (resp *ListAvailableHotelsResponse) FlattenRates() []RateResult {
+	var results []RateResult
+	for _, hotel := range resp.Hotels.Hotels {
+		for _, room := range hotel.Rooms {
+			for _, rate := range room.Rates {
+				results = append(results, RateResult{
+					HotelCode: hotel.Code,
+					HotelName: hotel.Name,
+					RoomCode:  room.Code,
+					RoomName:  room.Name,
+					Currency:  hotel.Currency,
+					Rate:      rate,
+				})
+			}
+		}
+	}
+	return results
+}
+
+// ShiftAlternative is an alternative stay of the hotel for shifted dates.
+type ShiftAlternative struct {
+	HotelCode int
+	RoomCode  string
+	Rate      ShiftRate
+}
+
+// BestShiftRate returns shift rate with check-in closest to the requested one,
+// the cheapest by net price is chosen among equally close alternatives.
+func (resp *ListAvailableHotelsResponse) BestShiftRate() (ShiftAlternative, bool) {
+	var (
+		best     ShiftAlternative
+		bestDiff time.Duration
+		found    bool
+	)
+	checkIn := time.Time(resp.Hotels.CheckIn)
+	for _, hotel := range resp.Hotels.Hotels {
+		for _, room := range hotel.Rooms {
+			for _, rate := range room.Rates {
+				for _, shift := range rate.ShiftRates {
+					diff := time.Time(shift.CheckIn).Sub(checkIn)
+					if diff < 0 {
+						diff = -diff
+					}
+					if found && (diff > bestDiff || diff == bestDiff && !decimal.Decimal(shift.Net).LessThan(decimal.Decimal(best.Rate.Net))) {
+						continue
+					}
+					best = ShiftAlternative{HotelCode: hotel.Code, RoomCode: room.Code, Rate: shift}
+					bestDiff, found = diff, true
+				}
+			}
+		}
+	}
+	return best, found
+}
+
+// BoardDescription resolves rate BoardCode to the description using boards
+// reference obtained from ListBoards. Falls back to BoardName if board is unknown.
+func (rate Rate) BoardDescription(boards []Board) string {
+	for _, board := range boards {
+		if board.Code == rate.BoardCode && board.Description.Content != "" {
+			return board.Description.Content
+		}
+	}
+	return rate.BoardName
+}
+
+// BoardGroup resolves rate BoardCode to the board group code: the board is looked up in boards
+// reference obtained from ListBoards and its MultiLingualCode in board groups reference obtained
+// from ListBoardGroups. Returns empty string if board or group is unknown.
+func (rate Rate) BoardGroup(boards []Board, groups []BoardGroup) string {
+	for _, board := range boards {
+		if board.Code != rate.BoardCode {
+			continue
+		}
+		for _, group := range groups {
+			if group.Code == board.MultiLingualCode {
+				return group.Code
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// FreeCancellationUntil returns the moment from which the first cancellation penalty
+// of any booking room is charged, i.e. booking can be cancelled free of charge before it.
+// Returns false if booking rates have no cancellation policies.
+func (b *Booking) FreeCancellationUntil() (time.Time, bool) {
+	var (
+		earliest time.Time
+		found    bool
+	)
+	for _, room := range b.Hotel.Rooms {
+		for _, rate := range room.Rates {
+			if from, ok := rate.earliestPenalty(); ok && (!found || from.Before(earliest)) {
+				earliest, found = from, true
+			}
+		}
+	}
+	return earliest, found
+}
+
+// bookingSchemaVersion is the version of the format written by Booking.MarshalStable.
+const bookingSchemaVersion = 1
+
+// storedBooking is the envelope of the stored booking.
+type storedBooking struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	Booking       json.RawMessage `json:"booking"`
+}
+
+// MarshalStable encodes booking for persistence. Encoded booking is wrapped into
+// versioned envelope and should be decoded with UnmarshalStableBooking.
+func (b *Booking) MarshalStable() ([]byte, error) {
+	booking, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(storedBooking{
+		SchemaVersion: bookingSchemaVersion,
+		Booking:       booking,
+	})
+}
+
+// UnmarshalStableBooking decodes booking encoded by Booking.MarshalStable of any version.
+// Unknown fields are ignored and missing fields are left zero, so bookings stored
+// before the structure changed can still be loaded. Booking JSON stored as is
+// (without the envelope, e.g. taken from the API response) is accepted as well.
+func UnmarshalStableBooking(data []byte) (*Booking, error) {
+	var stored storedBooking
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode stored booking: %w", err)
+	}
+	if len(stored.Booking) == 0 || string(stored.Booking) == "null" {
+		stored.Booking = data
+	}
+	var booking Booking
+	if err := json.Unmarshal(stored.Booking, &booking); err != nil {
+		return nil, fmt.Errorf("failed to decode stored booking of version %d: %w", stored.SchemaVersion, err)
+	}
+	return &booking, nil
+}
+
+// Money is an amount in the currency.
+type Money struct {
+	Amount   Amount `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Equal reports whether amounts of m and other are equal. Amounts in different currencies
+// can't be compared, so ErrCurrencyMismatch is returned. Empty currency is considered
+// unknown and matches any currency.
+func (m Money) Equal(other Money) (bool, error) {
+	if m.Currency != "" && other.Currency != "" && m.Currency != other.Currency {
+		return false, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return decimal.Decimal(m.Amount).Equal(decimal.Decimal(other.Amount)), nil
+}
+
+// MarshalJSON encodes money with the amount in decimal places of the currency
+// (see Amount.StringCurrency), unlike Amount, which is always encoded with two.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   json.RawMessage `json:"amount"`
+		Currency string          `json:"currency"`
+	}{
+		Amount:   json.RawMessage(m.Amount.StringCurrency(m.Currency)),
+		Currency: m.Currency,
+	})
+}
+
+// MinMoney returns MinRate in the currency of the hotel. MinRate is decoded as float, it's converted
+// into the shortest decimal representing it, which is the value sent by the API.
+func (h *AvailableHotel) MinMoney() Money {
+	return Money{Amount: Amount(decimal.NewFromFloat(h.MinRate.Float())), Currency: h.Currency}
+}
+
+// MaxMoney returns MaxRate in the currency of the hotel, see MinMoney.
+func (h *AvailableHotel) MaxMoney() Money {
+	return Money{Amount: Amount(decimal.NewFromFloat(h.MaxRate.Float())), Currency: h.Currency}
+}
+
+// TotalMoney returns TotalNet in the currency of the hotel.
+func (h *CheckRateHotel) TotalMoney() Money {
+	return Money{Amount: h.TotalNet, Currency: h.Currency}
+}
+
+// TotalMoney returns TotalNet in the currency of the booking.
+func (h *BookingHotel) TotalMoney() Money {
+	return Money{Amount: h.TotalNet, Currency: h.Currency}
+}
+
+// Summary returns the short presentation of the booking. Total and currency of the booking
+// are used, falling back to the ones of the hotel. Dates which can't be parsed are left zero.
+func (b *Booking) Summary() BookingSummary {
+	if b == nil {
+		return BookingSummary{}
+	}
+	summary := BookingSummary{
+		Reference: b.Reference,
+		HotelName: b.Hotel.Name,
+		Total:     b.TotalNet,
+		Currency:  b.Currency,
+	}
+	if decimal.Decimal(summary.Total).IsZero() {
+		summary.Total = b.Hotel.TotalNet
+	}
+	if summary.Currency == "" {
+		summary.Currency = b.Hotel.Currency
+	}
+	if checkIn, err := time.Parse("2006-01-02", b.Hotel.CheckIn); err == nil {
+		summary.CheckIn = Datetime(checkIn)
+	}
+	if checkOut, err := time.Parse("2006-01-02", b.Hotel.CheckOut); err == nil {
+		summary.CheckOut = Datetime(checkOut)
+	}
+	if !summary.CheckIn.IsZero() && time.Time(summary.CheckOut).After(time.Time(summary.CheckIn)) {
+		summary.Nights = int(time.Time(summary.CheckOut).Sub(time.Time(summary.CheckIn)).Hours() / 24)
+	}
+	for _, room := range b.Hotel.Rooms {
+		// Room may be booked multiple times by a single rate.
+		count := 0
+		for _, rate := range room.Rates {
+			count += rate.Rooms
+		}
+		if count == 0 {
+			count = 1
+		}
+		summary.Rooms += count
+	}
+	return summary
+}
+
+// BillingInfo returns supplier and invoicing company of the booking,
+// fields which aren't returned are left empty.
+func (b *Booking) BillingInfo() BillingInfo {
+	if b == nil {
+		return BillingInfo{}
+	}
+	info := BillingInfo{
+		InvoiceCompanyCode:        b.InvoiceCompany.Code,
+		InvoiceCompanyName:        b.InvoiceCompany.Name,
+		InvoiceRegistrationNumber: b.InvoiceCompany.RegistrationNumber,
+	}
+	if b.Hotel.Supplier != nil {
+		info.SupplierName = b.Hotel.Supplier.Name
+		info.SupplierVATNumber = b.Hotel.Supplier.VATNumber
+	}
+	return info
+}
+
+// TaxSummary returns sums of taxes included into the rate price and taxes excluded from it
+// (payable at the hotel). Taxes are summed in the client currency if all of them report it,
+// otherwise in the net currency. If taxes are reported in mixed currencies, they can't be summed
+// and ok is false.
+func (rate CheckRate) TaxSummary() (included Money, excluded Money, ok bool) {
+	if rate.Taxes == nil {
+		return included, excluded, true
+	}
+	var includedTaxes, excludedTaxes []Tax
+	for _, tax := range rate.Taxes.Taxes {
+		if tax.Included {
+			includedTaxes = append(includedTaxes, tax)
+		} else {
+			excludedTaxes = append(excludedTaxes, tax)
+		}
+	}
+	included, includedOk := sumTaxes(includedTaxes)
+	excluded, excludedOk := sumTaxes(excludedTaxes)
+	if !includedOk || !excludedOk {
+		return Money{}, Money{}, false
+	}
+	return included, excluded, true
+}
+
+// FetchComments fetches detailed comments of the rate, which are valid on the check-in date of the rate key.
+func (rate CheckRate) FetchComments(ctx context.Context, api *API) (*GetRateCommentsResponse, error) {
+	info, err := ParseRateKey(rate.RateKey)
+	if err != nil {
+		return nil, err
+	}
+	return api.GetRateComments(ctx, rate.RateCommentdsID, info.CheckIn)
+}
+
+func sumTaxes(taxes []Tax) (Money, bool) {
+	if len(taxes) == 0 {
+		return Money{}, true
+	}
+	sum := func(client bool) (Money, bool) {
+		var (
+			total    decimal.Decimal
+			currency string
+		)
+		for i, tax := range taxes {
+			amount, taxCurrency := tax.Amount, tax.Currency
+			if client {
+				amount, taxCurrency = tax.ClientAmount, tax.ClientCurrency
+			}
+			if taxCurrency == "" || (i > 0 && taxCurrency != currency) {
+				return Money{}, false
+			}
+			currency = taxCurrency
+			total = total.Add(decimal.Decimal(amount))
+		}
+		return Money{Amount: Amount(total), Currency: currency}, true
+	}
+	if money, ok := sum(true); ok {
+		return money, true
+	}
+	return sum(false)
+}
+
+// Cutoff returns the moment from which the penalty is charged. If From is absent, it's computed
+// as HoursBefore before the check-in (midnight of the check-in date, as hotel time zone is unknown).
+func (policy CancellationPolicy) Cutoff(checkIn Datetime) time.Time {
+	if from := time.Time(policy.From); !from.IsZero() || policy.HoursBefore == nil {
+		return from
+	}
+	return time.Time(checkIn).Add(-time.Duration(*policy.HoursBefore) * time.Hour)
+}
+
+// earliestPenalty returns the earliest date from which cancellation penalty is charged.
+// Check-in of the policies without From is taken from the rateKey.
+func (rate Rate) earliestPenalty() (time.Time, bool) {
+	var (
+		earliest time.Time
+		found    bool
+		checkIn  Datetime
+	)
+	if info, err := ParseRateKey(rate.RateKey); err == nil {
+		checkIn = info.CheckIn
+	}
+	for _, policy := range rate.CancellationPolicies {
+		from := policy.Cutoff(checkIn)
+		if !found || from.Before(earliest) {
+			earliest, found = from, true
+		}
+	}
+	return earliest, found
+}
+
 // Ref - https://developer.hotelbeds.com/documentation/hotels/booking-api/api-reference/#operation/availability
 func (api *API) ListAvailableHotels(ctx context.Context, inp *ListAvailableHotelsInput) (*ListAvailableHotelsResponse, error) {
 	if err := inp.Validate(); err != nil {
 		return nil, err
 	}
+	if inp.Language == "" && api.options.Language != "" {
+		withLanguage := *inp
+		withLanguage.Language = api.options.Language
+		inp = &withLanguage
+	}
 	return clientx.NewRequestBuilder[ListAvailableHotelsInput, ListAvailableHotelsResponse](api.API).
-		Post("/hotel-api/1.0/hotels", inp, clientx.WithRequestHeaders(api.buildHeaders())).
+		Post(api.path("/hotel-api/1.0/hotels"), inp, api.withRequestHeaders()).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/booking-api/api-reference/#operation/checkRate
 func (api *API) ListCheckRates(ctx context.Context, inp *ListCheckRatesInput) (*ListCheckRatesResponse, error) {
 	return clientx.NewRequestBuilder[ListCheckRatesInput, ListCheckRatesResponse](api.API).
-		Post("/hotel-api/1.0/checkrates", inp, clientx.WithRequestHeaders(api.buildHeaders())).
+		Post(api.path("/hotel-api/1.0/checkrates"), inp, api.withRequestHeaders()).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // https://developer.hotelbeds.com/documentation/hotels/booking-api/api-reference/#operation/bookingDetail
 func (api *API) GetBooking(ctx context.Context, id string) (*GetBookingResponse, error) {
 	return clientx.NewRequestBuilder[struct{}, GetBookingResponse](api.API).
-		Get("/hotel-api/1.0/bookings/"+id, clientx.WithRequestHeaders(api.buildHeaders())).
+		Get(api.path("/hotel-api/1.0/bookings/"+id), api.withRequestHeaders()).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
+}
+
+// getBookingsConcurrency is the maximal number of GetBooking requests made concurrently by GetBookings.
+const getBookingsConcurrency = 5
+
+// GetBookings fetches bookings by references concurrently (up to getBookingsConcurrency requests at once).
+// Fetched bookings and errors of the failed requests are returned separately by the booking reference,
+// so one missing booking doesn't fail the others.
+func (api *API) GetBookings(ctx context.Context, ids []string) (map[string]*Booking, map[string]error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, getBookingsConcurrency)
+		bookings = make(map[string]*Booking, len(ids))
+		errs     = make(map[string]error)
+		seen     = make(map[string]bool, len(ids))
+	)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			errs[id] = ctx.Err()
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(id string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			resp, err := api.GetBooking(ctx, id)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				errs[id] = err
+			case resp.Booking == nil:
+				errs[id] = ErrBookingDoesNotExist
+			default:
+				bookings[id] = resp.Booking
+			}
+		}(id)
+	}
+	wg.Wait()
+	return bookings, errs
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/booking-api/api-reference/#operation/bookingList
-func (api *API) ListBookings(ctx context.Context, inp *CancelBookingInput) (*CancelBookingResponse, error) {
-	return clientx.NewRequestBuilder[CancelBookingInput, CancelBookingResponse](api.API).
-		Delete("/hotel-api/1.0/bookings", inp, clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+func (api *API) ListBookings(ctx context.Context, inp *ListBookingsInput) (*ListBookingsResponse, error) {
+	return clientx.NewRequestBuilder[ListBookingsInput, ListBookingsResponse](api.API).
+		Get(api.path("/hotel-api/1.0/bookings"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
+}
+
+// CountBookings returns the total number of bookings matching inp.
+// Only a single booking is requested, inp paging (From, To) is ignored.
+func (api *API) CountBookings(ctx context.Context, inp *ListBookingsInput) (int, error) {
+	page := *inp
+	page.From, page.To = 1, 1
+	resp, err := api.ListBookings(ctx, &page)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Bookings.Total, nil
+}
+
+// HotelWithAvailability is static content of the hotel along with its live rates.
+// If one of the requests fails, the other part is still returned and the error
+// is reported in ContentErr or AvailabilityErr.
+type HotelWithAvailability struct {
+	// Hotel is nil if content request failed or the hotel wasn't found.
+	Hotel *Hotel
+	// Availability is nil if availability request failed or the hotel has no availability.
+	Availability    *AvailableHotel
+	ContentErr      error
+	AvailabilityErr error
+}
+
+// GetHotelWithAvailability concurrently fetches content of the hotel and its availability for the stay.
+// Error is returned only if both requests fail, otherwise errors are reported in the result.
+func (api *API) GetHotelWithAvailability(ctx context.Context, code int, stay Stay, occupancies []Occupancy) (*HotelWithAvailability, error) {
+	var (
+		result HotelWithAvailability
+		wg     sync.WaitGroup
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		resp, err := api.GetHotelDetails(ctx, []int{code}, &GetHotelDetailsInput{Language: api.options.Language})
+		if err != nil {
+			result.ContentErr = err
+			return
+		}
+		for i := range resp.Hotels {
+			if resp.Hotels[i].Code == code {
+				result.Hotel = &resp.Hotels[i]
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		resp, err := api.ListAvailableHotels(ctx, &ListAvailableHotelsInput{
+			Stay:        stay,
+			Occupancies: occupancies,
+			Hotels:      FilterHotel{HotelCodes: []int{code}},
+		})
+		if err != nil {
+			result.AvailabilityErr = err
+			return
+		}
+		for i := range resp.Hotels.Hotels {
+			if resp.Hotels.Hotels[i].Code == code {
+				result.Availability = &resp.Hotels.Hotels[i]
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	if result.ContentErr != nil && result.AvailabilityErr != nil {
+		return nil, fmt.Errorf("failed to get hotel content: %v; failed to list availability: %w", result.ContentErr, result.AvailabilityErr)
+	}
+	return &result, nil
+}
+
+// RecheckBookingResult is the current pricing of the booking rates.
+type RecheckBookingResult struct {
+	CheckRates *ListCheckRatesResponse
+	// TotalNet stored in the booking.
+	PreviousTotalNet Amount
+	// TotalNet returned by checkrates.
+	CurrentTotalNet Amount
+	PriceChanged    bool
+	// Unavailable is true if checkrates returned no hotel, i.e. the rates are no longer
+	// available. Prices aren't compared then.
+	Unavailable bool
+}
+
+// RecheckBooking re-validates pricing of the booking by checking rates of all its rooms.
+func (api *API) RecheckBooking(ctx context.Context, booking *Booking) (*RecheckBookingResult, error) {
+	var rooms []ListCheckRatesRoom
+	for _, room := range booking.Hotel.Rooms {
+		for _, rate := range room.Rates {
+			if rate.RateKey != "" {
+				rooms = append(rooms, ListCheckRatesRoom{RateKey: rate.RateKey, Paxes: room.Paxes})
+			}
+		}
+	}
+	if len(rooms) == 0 {
+		return nil, errors.New("booking has no rate keys")
+	}
+
+	resp, err := api.ListCheckRates(ctx, &ListCheckRatesInput{Rooms: rooms})
+	if err != nil {
+		return nil, err
+	}
+	result := &RecheckBookingResult{
+		CheckRates:       resp,
+		PreviousTotalNet: booking.Hotel.TotalNet,
+	}
+	if resp.Hotel == nil {
+		result.Unavailable = true
+		return result, nil
+	}
+	current := resp.Hotel.TotalMoney()
+	result.CurrentTotalNet = current.Amount
+	equal, err := booking.Hotel.TotalMoney().Equal(current)
+	if err != nil {
+		return nil, err
+	}
+	result.PriceChanged = !equal
+	return result, nil
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/booking-api/api-reference/#operation/booking
 func (api *API) ConfirmBooking(ctx context.Context, inp *ConfirmBookingInput) (*ConfirmBookingResponse, error) {
+	if err := inp.Validate(); err != nil {
+		return nil, err
+	}
+	if inp.CreationUser == "" && api.options.CreationUser != "" {
+		withCreationUser := *inp
+		withCreationUser.CreationUser = api.options.CreationUser
+		inp = &withCreationUser
+	}
 	return clientx.NewRequestBuilder[ConfirmBookingInput, ConfirmBookingResponse](api.API).
-		Post("/hotel-api/1.2/bookings", inp, clientx.WithRequestHeaders(api.buildHeaders())).
+		Post(api.path("/hotel-api/1.2/bookings"), inp, api.withRequestHeaders()).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
+}
+
+// SafeConfirmBookingRetry wraps retry condition passed to WithRetry, so ConfirmBooking is retried
+// only if the request wasn't sent to the server (see IsPreProcessingError). Once the request is sent,
+// booking might be confirmed even though the response is an error (e.g. 5xx or timeout), and the retry
+// would create a duplicate. In such case, look the booking up by ListBookings with FilterClientReference
+// (unique ClientReference per confirmation) instead. Other requests are retried by cond, if it's not nil.
+func SafeConfirmBookingRetry(cond clientx.RetryCond) clientx.RetryCond {
+	return func(resp *http.Response, err error) bool {
+		if isConfirmBookingAttempt(resp, err) {
+			return IsPreProcessingError(err)
+		}
+		return cond != nil && cond(resp, err)
+	}
+}
+
+// isConfirmBookingAttempt checks if response or error of the request belongs to ConfirmBooking.
+func isConfirmBookingAttempt(resp *http.Response, err error) bool {
+	var method, path string
+	if resp != nil && resp.Request != nil {
+		method, path = resp.Request.Method, resp.Request.URL.Path
+	} else {
+		var urlErr *url.Error
+		if !errors.As(err, &urlErr) {
+			return false
+		}
+		u, parseErr := url.Parse(urlErr.URL)
+		if parseErr != nil {
+			return false
+		}
+		// Op of the request error is the method, e.g. "Post".
+		method, path = strings.ToUpper(urlErr.Op), u.Path
+	}
+	return method == http.MethodPost && strings.HasSuffix(path, "/hotel-api/1.2/bookings")
+}
+
+// bookingPollInterval is the interval between booking status checks in ConfirmBookingAndWait.
+var bookingPollInterval = 2 * time.Second
+
+// ConfirmBookingAndWait confirms booking and, if the booking isn't confirmed or cancelled
+// immediately, polls it with GetBooking until it reaches terminal status or timeout expires.
+// On timeout the last known booking is returned along with the error.
+func (api *API) ConfirmBookingAndWait(ctx context.Context, inp *ConfirmBookingInput, timeout time.Duration) (*ConfirmBookingResponse, error) {
+	resp, err := api.ConfirmBooking(ctx, inp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Booking == nil || resp.Booking.Status.IsTerminal() {
+		return resp, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(bookingPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return resp, fmt.Errorf("booking %s is %s: %w", resp.Booking.Reference, resp.Booking.Status, ctx.Err())
+		case <-ticker.C:
+		}
+
+		booking, err := api.GetBooking(ctx, resp.Booking.Reference)
+		if err != nil {
+			return resp, err
+		}
+		if booking.Booking != nil {
+			resp = &ConfirmBookingResponse{Audit: booking.Audit, Booking: booking.Booking}
+			if booking.Booking.Status.IsTerminal() {
+				return resp, nil
+			}
+		}
+	}
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/booking-api/api-reference/#operation/bookingChange
 func (api *API) ChangeBooking(ctx context.Context, id string, inp *ChangeBookingInput) (*ChangeBookingResponse, error) {
+	if err := inp.Validate(); err != nil {
+		return nil, err
+	}
 	return clientx.NewRequestBuilder[ChangeBookingInput, ChangeBookingResponse](api.API).
-		Put("/hotel-api/1.0/bookings/"+id, inp, clientx.WithRequestHeaders(api.buildHeaders())).
+		Put(api.path("/hotel-api/1.0/bookings/"+id), inp, api.withRequestHeaders()).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/booking-api/api-reference/#operation/bookingCancellation
 func (api *API) CancelBooking(ctx context.Context, id string, inp *CancelBookingInput) (*CancelBookingResponse, error) {
+	if err := inp.Validate(); err != nil {
+		return nil, err
+	}
 	return clientx.NewRequestBuilder[CancelBookingInput, CancelBookingResponse](api.API).
-		Delete("/hotel-api/1.0/bookings/"+id, nil, clientx.WithRequestHeaders(api.buildHeaders())).
+		Delete(api.path("/hotel-api/1.0/bookings/"+id), nil, api.withRequestHeaders()).
 		WithQueryParams("url", *inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
@@ -5,9 +5,18 @@ package hotelbeds
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/h2non/gock.v1"
 )
@@ -47,6 +56,102 @@ func TestListAvailableHotels(t *testing.T) {
 	assert.Equal(t, 1, len(resp.Hotels.Hotels))
 }
 
+func TestListAvailableHotelsNamedPaxes(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.0/hotels").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return false, err
+			}
+			return strings.Contains(string(body), `"paxes":[{"type":"AD","age":30,"name":"John","surname":"Doe"}]`), nil
+		}).
+		Reply(200).
+		File("fixtures/200-list-available-hotels.json")
+
+	inp := &ListAvailableHotelsInput{
+		Stay: Stay{
+			CheckIn:  "2024-04-02",
+			CheckOut: "2024-04-03",
+		},
+		Occupancies: []Occupancy{
+			{
+				Rooms:  1,
+				Adults: 1,
+				Paxes: []Pax{
+					{
+						Type:    PaxTypeAdult,
+						Age:     30,
+						Name:    "John",
+						Surname: "Doe",
+					},
+				},
+			},
+		},
+		Hotels: FilterHotel{
+			HotelCodes: []int{6619},
+		},
+		RequirePaxNames: true,
+	}
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	resp, err := client.ListAvailableHotels(context.TODO(), inp)
+	assert.NoError(t, err)
+	assert.NotNil(t, resp)
+
+	inp.Occupancies[0].Paxes[0].Surname = ""
+	_, err = client.ListAvailableHotels(context.TODO(), inp)
+	assert.Equal(t, ValidationErrors{{FieldName: "Occupancy.Paxes.Surname", Required: true}}, err)
+}
+
+func TestListAvailableHotelsExtra(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.0/hotels").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return false, err
+			}
+			return strings.HasSuffix(string(body), `"accommodations":["HOTEL"],"inclusions":{"included":true},"review":3}`+"\n"), nil
+		}).
+		Reply(200).
+		File("fixtures/200-list-available-hotels.json")
+
+	inp := &ListAvailableHotelsInput{
+		Stay: Stay{
+			CheckIn:  "2024-04-02",
+			CheckOut: "2024-04-03",
+		},
+		Occupancies: []Occupancy{
+			{
+				Rooms:  1,
+				Adults: 1,
+			},
+		},
+		Hotels: FilterHotel{
+			HotelCodes: []int{6619},
+		},
+		Accommodations: []string{"HOTEL"},
+		Extra: map[string]any{
+			"review":     3,
+			"inclusions": map[string]bool{"included": true},
+		},
+	}
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	resp, err := client.ListAvailableHotels(context.TODO(), inp)
+	assert.NoError(t, err)
+	assert.NotNil(t, resp)
+
+	inp.Extra = map[string]any{"stay": nil}
+	_, err = json.Marshal(inp)
+	assert.ErrorContains(t, err, `extra field "stay" conflicts with the input field`)
+}
+
 func TestListCheckRates(t *testing.T) {
 	defer gock.Off()
 
@@ -110,3 +215,1630 @@ func TestConfirmBooking(t *testing.T) {
 	assert.Equal(t, BookingStatus("CONFIRMED"), resp.Booking.Status)
 	assert.Equal(t, 1, len(resp.Booking.Hotel.Rooms))
 }
+
+func TestBookingSummary(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-confirm-booking.json")
+	assert.NoError(t, err)
+
+	var resp ConfirmBookingResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	summary := resp.Booking.Summary()
+	assert.Equal(t, "207-12306403", summary.Reference)
+	assert.Equal(t, "Castello Di Velona, Resort Thermal SPA & Winery", summary.HotelName)
+	assert.Equal(t, "2024-04-06", summary.CheckIn.String())
+	assert.Equal(t, "2024-04-08", summary.CheckOut.String())
+	assert.Equal(t, 2, summary.Nights)
+	assert.Equal(t, "899.23", decimal.Decimal(summary.Total).StringFixed(2))
+	assert.Equal(t, "GBP", summary.Currency)
+	assert.Equal(t, 1, summary.Rooms)
+
+	// Hotel totals are used when the booking ones are absent.
+	resp.Booking.TotalNet = Amount{}
+	resp.Booking.Currency = ""
+	resp.Booking.Hotel.CheckOut = ""
+	summary = resp.Booking.Summary()
+	assert.Equal(t, "899.23", decimal.Decimal(summary.Total).StringFixed(2))
+	assert.Equal(t, "GBP", summary.Currency)
+	assert.True(t, summary.CheckOut.IsZero())
+	assert.Equal(t, 0, summary.Nights)
+
+	var booking *Booking
+	assert.Equal(t, BookingSummary{}, booking.Summary())
+}
+
+func TestBookingBillingInfo(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-confirm-booking.json")
+	assert.NoError(t, err)
+
+	var resp ConfirmBookingResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+	assert.Equal(t, BillingInfo{
+		SupplierName:              "HOTELBEDS PRODUCT,S.L.U.",
+		SupplierVATNumber:         "ESB38877676",
+		InvoiceCompanyCode:        "CH1",
+		InvoiceCompanyName:        "HOTELBEDS SWITZERLAND AG",
+		InvoiceRegistrationNumber: "CHE425060629",
+	}, resp.Booking.BillingInfo())
+
+	booking := &Booking{InvoiceCompany: InvoiceCompany{Code: "E14"}}
+	assert.Equal(t, BillingInfo{InvoiceCompanyCode: "E14"}, booking.BillingInfo())
+
+	booking = nil
+	assert.Equal(t, BillingInfo{}, booking.BillingInfo())
+}
+
+func TestCheapestRefundable(t *testing.T) {
+	before := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	policy := func(from time.Time) []CancellationPolicy {
+		return []CancellationPolicy{{Amount: Amount(decimal.NewFromInt(10)), From: TimestampTZ(from)}}
+	}
+
+	room := AvailableHotelRoom{
+		Rates: []Rate{
+			{RateKey: "non-refundable", Net: Amount(decimal.NewFromInt(50)), CancellationPolicies: policy(before.Add(-time.Hour))},
+			{RateKey: "refundable-expensive", Net: Amount(decimal.NewFromInt(120)), CancellationPolicies: policy(before.Add(48 * time.Hour))},
+			{RateKey: "refundable-cheap", Net: Amount(decimal.NewFromInt(80)), CancellationPolicies: policy(before.Add(24 * time.Hour))},
+			{RateKey: "penalty-at-before", Net: Amount(decimal.NewFromInt(60)), CancellationPolicies: policy(before)},
+		},
+	}
+	rate, ok := room.CheapestRefundable(before)
+	assert.True(t, ok)
+	assert.Equal(t, "refundable-cheap", rate.RateKey)
+
+	room.Rates = append(room.Rates, Rate{RateKey: "no-policies", Net: Amount(decimal.NewFromInt(70))})
+	rate, ok = room.CheapestRefundable(before)
+	assert.True(t, ok)
+	assert.Equal(t, "no-policies", rate.RateKey)
+
+	room.Rates = room.Rates[:1]
+	_, ok = room.CheapestRefundable(before)
+	assert.False(t, ok)
+}
+
+func TestAvailableHotelCategoryContent(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-list-available-hotels-multi.json")
+	assert.NoError(t, err)
+
+	var resp ListAvailableHotelsResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	hotel := resp.Hotels.Hotels[0]
+	assert.Equal(t, Content{Content: "4 STARS"}, hotel.CategoryContent())
+	assert.Equal(t, Content{Content: "West End"}, hotel.ZoneContent())
+
+	data, err = os.ReadFile("fixtures/200-get-hotel-details.json")
+	assert.NoError(t, err)
+
+	var details GetHotelDetailsResponse
+	assert.NoError(t, json.Unmarshal(data, &details))
+	assert.Equal(t, Content{Content: "The Savoy"}, details.Hotels[0].Name)
+}
+
+func TestAvailableHotelRoomDedupRates(t *testing.T) {
+	rate := func(key, board string, adults, children int, net string) Rate {
+		return Rate{RateKey: key, BoardCode: board, Rooms: 1, Adults: adults, Children: children, Net: Amount(decimal.RequireFromString(net))}
+	}
+	room := AvailableHotelRoom{
+		Code: "DBL.ST",
+		Rates: []Rate{
+			rate("ro-1", "RO", 2, 0, "212.40"),
+			rate("bb-1", "BB", 2, 0, "245.00"),
+			rate("ro-2", "RO", 2, 0, "212.35"),
+			rate("ro-child", "RO", 2, 1, "230.00"),
+			rate("bb-2", "BB", 2, 0, "245.10"),
+			rate("ro-3", "RO", 2, 0, "212.38"),
+		},
+	}
+
+	deduped := room.DedupRates()
+	assert.Equal(t, "DBL.ST", deduped.Code)
+	var keys []string
+	for _, rate := range deduped.Rates {
+		keys = append(keys, rate.RateKey)
+	}
+	assert.Equal(t, []string{"ro-2", "bb-1", "ro-child"}, keys)
+	assert.Equal(t, 6, len(room.Rates))
+	assert.Equal(t, "ro-1", room.Rates[0].RateKey)
+}
+
+func TestBookingHotelVerifyTotals(t *testing.T) {
+	for _, tc := range []struct {
+		fixture string
+		err     string
+	}{
+		{fixture: "fixtures/200-confirm-booking.json"},
+		{fixture: "fixtures/200-confirm-booking-totals-mismatch.json", err: "totalNet mismatch: reported 950.00, sum of rates 899.23"},
+	} {
+		data, err := os.ReadFile(tc.fixture)
+		assert.NoError(t, err)
+
+		var resp ConfirmBookingResponse
+		assert.NoError(t, json.Unmarshal(data, &resp))
+
+		err = resp.Booking.Hotel.VerifyTotals()
+		if tc.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, tc.err)
+		}
+	}
+
+	hotel := &BookingHotel{
+		TotalNet:         Amount(decimal.NewFromInt(100)),
+		TotalSellingRate: Amount(decimal.NewFromInt(130)),
+		Rooms: []BookingRoom{
+			{Rates: []Rate{{Net: Amount(decimal.NewFromInt(60)), Selling: Amount(decimal.NewFromInt(70))}}},
+			{Rates: []Rate{{Net: Amount(decimal.NewFromInt(40)), Selling: Amount(decimal.NewFromInt(50))}}},
+		},
+	}
+	assert.EqualError(t, hotel.VerifyTotals(), "totalSellingRate mismatch: reported 130.00, sum of rates 120.00")
+
+	hotel.Currency = "JPY"
+	assert.EqualError(t, hotel.VerifyTotals(), "totalSellingRate mismatch: reported 130, sum of rates 120")
+}
+
+func TestFilterRooms(t *testing.T) {
+	inp := &ListAvailableHotelsInput{
+		Stay: Stay{
+			CheckIn:  "2024-04-02",
+			CheckOut: "2024-04-03",
+		},
+		Occupancies: []Occupancy{{Rooms: 1, Adults: 2}},
+		Rooms: &FilterRooms{
+			Codes:    []string{"DBL.ST", "TWN.ST"},
+			Included: false,
+		},
+	}
+	assert.NoError(t, inp.Validate())
+
+	data, err := json.Marshal(inp)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"rooms":{"room":["DBL.ST","TWN.ST"],"included":false}`)
+
+	inp.Rooms.Codes = nil
+	assert.Equal(t, ValidationErrors{{FieldName: "FilterRooms.Room", Required: true}}, inp.Validate())
+}
+
+func TestGeolocationValidate(t *testing.T) {
+	latitude, longitude := 0.0, 0.0
+	geo := &Geolocation{
+		Latitude:  &latitude,
+		Longitude: &longitude,
+		Radius:    20,
+	}
+	assert.NoError(t, geo.Validate())
+
+	data, err := json.Marshal(geo)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"latitude":0,"longitude":0,"radius":"20","unit":"km"}`, string(data))
+	assert.Equal(t, Unit(""), geo.Unit)
+
+	geo.Radius = 0
+	assert.Equal(t, ValidationErrors{{FieldName: "Radius", Min: 1, Max: 200}}, geo.Validate())
+
+	geo.Latitude, geo.Radius, geo.Unit = nil, 20, UnitMiles
+	assert.Equal(t, ValidationErrors{{FieldName: "Latitude", Required: true}}, geo.Validate())
+}
+
+func TestStaysFor(t *testing.T) {
+	start := Datetime(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+	end := Datetime(time.Date(2024, 4, 8, 0, 0, 0, 0, time.UTC))
+
+	stays := StaysFor(start, end, 1)
+	assert.Equal(t, 7, len(stays))
+	assert.Equal(t, Stay{CheckIn: "2024-04-01", CheckOut: "2024-04-02"}, stays[0])
+	assert.Equal(t, Stay{CheckIn: "2024-04-07", CheckOut: "2024-04-08"}, stays[6])
+	for _, stay := range stays {
+		assert.NoError(t, stay.Validate())
+	}
+
+	stays = StaysFor(start, end, 3)
+	assert.Equal(t, Stay{CheckIn: "2024-04-07", CheckOut: "2024-04-10"}, stays[6])
+
+	assert.Nil(t, StaysFor(start, end, 0))
+	assert.Nil(t, StaysFor(end, start, 1))
+}
+
+func TestTotalOccupancy(t *testing.T) {
+	inp := ListAvailableHotelsInput{Occupancies: SameOccupancyRooms(1, 2)}
+	rooms, adults, children := inp.TotalOccupancy()
+	assert.Equal(t, []int{1, 2, 0}, []int{rooms, adults, children})
+
+	inp.Occupancies = append(SameOccupancyRooms(2, 2, 5, 8), Occupancy{Rooms: 1, Adults: 1})
+	rooms, adults, children = inp.TotalOccupancy()
+	assert.Equal(t, []int{3, 5, 4}, []int{rooms, adults, children})
+
+	rooms, adults, children = ListAvailableHotelsInput{}.TotalOccupancy()
+	assert.Equal(t, []int{0, 0, 0}, []int{rooms, adults, children})
+}
+
+func TestAvailableHotelEnrichDescriptions(t *testing.T) {
+	var boards ListBoardsResponse
+	data, err := os.ReadFile("fixtures/200-list-types-boards.json")
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &boards))
+	var rooms ListRoomsResponse
+	data, err = os.ReadFile("fixtures/200-list-types-rooms.json")
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &rooms))
+
+	newHotel := func() *AvailableHotel {
+		return &AvailableHotel{
+			Rooms: []AvailableHotelRoom{
+				{
+					Code: "APT.0E",
+					Name: "apartment",
+					Rates: []Rate{
+						{BoardCode: "AB", BoardName: "BREAKFAST"},
+						{BoardCode: "RO", BoardName: "ROOM ONLY"},
+					},
+					Upselling: []UpsellingRate{{Rate: Rate{BoardCode: "AI", BoardName: "ALL INC"}}},
+				},
+				{Code: "DBL.ST", Name: "standard room", Rates: []Rate{{BoardCode: "AI"}}},
+			},
+		}
+	}
+
+	hotel := newHotel()
+	hotel.EnrichDescriptions(boards.Boards, rooms.Rooms, "ENG")
+	assert.Equal(t, "APARTMENT basement", hotel.Rooms[0].Name)
+	assert.Equal(t, "AMERICAN BREAKFAST", hotel.Rooms[0].Rates[0].BoardName)
+	assert.Equal(t, "ALL INCLUSIVE", hotel.Rooms[0].Upselling[0].BoardName)
+	assert.Equal(t, "ALL INCLUSIVE", hotel.Rooms[1].Rates[0].BoardName)
+	// Unknown codes keep their names.
+	assert.Equal(t, "ROOM ONLY", hotel.Rooms[0].Rates[1].BoardName)
+	assert.Equal(t, "standard room", hotel.Rooms[1].Name)
+
+	// Reference data in other language is ignored.
+	hotel = newHotel()
+	hotel.EnrichDescriptions(boards.Boards, rooms.Rooms, "CAS")
+	assert.Equal(t, newHotel(), hotel)
+}
+
+func TestFilterByAllotment(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-list-available-hotels-allotment.json")
+	assert.NoError(t, err)
+	var resp ListAvailableHotelsResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	filtered := resp.FilterByAllotment(5)
+	assert.Equal(t, 2, len(filtered.Hotels.Hotels))
+	assert.Equal(t, 6619, filtered.Hotels.Hotels[0].Code)
+	assert.Equal(t, 1, len(filtered.Hotels.Hotels[0].Rooms))
+	assert.Equal(t, 1, len(filtered.Hotels.Hotels[0].Rooms[0].Rates))
+	assert.Equal(t, 8, filtered.Hotels.Hotels[0].Rooms[0].Rates[0].Allotment)
+	assert.Equal(t, 6613, filtered.Hotels.Hotels[1].Code)
+	assert.Equal(t, resp.Hotels.Total, filtered.Hotels.Total)
+
+	filtered = resp.FilterByAllotment(9)
+	assert.Equal(t, 0, len(filtered.Hotels.Hotels))
+
+	// Response itself isn't modified.
+	assert.Equal(t, 3, len(resp.Hotels.Hotels))
+	assert.Equal(t, 2, len(resp.Hotels.Hotels[0].Rooms[0].Rates))
+	assert.Equal(t, 4, len(resp.FilterByAllotment(1).FlattenRates()))
+}
+
+func TestRateAfterOffers(t *testing.T) {
+	var rate Rate
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"net": "120.00",
+		"sellingRate": "150.00",
+		"offers": [
+			{"code": "9001", "name": "Early booking", "amount": "-12.50"},
+			{"code": "9002", "name": "Long stay", "amount": "-7.50"}
+		]
+	}`), &rate))
+	assert.Equal(t, "100.00", decimal.Decimal(rate.NetAfterOffers()).StringFixed(2))
+	assert.Equal(t, "130.00", decimal.Decimal(rate.SellingAfterOffers()).StringFixed(2))
+
+	// Positive offer amounts are discounts too.
+	rate.Offers[1].Amount = Amount(decimal.RequireFromString("7.50"))
+	assert.Equal(t, "130.00", decimal.Decimal(rate.SellingAfterOffers()).StringFixed(2))
+
+	rate.Selling = Amount{}
+	assert.True(t, decimal.Decimal(rate.SellingAfterOffers()).IsZero())
+}
+
+func TestRateDailyRates(t *testing.T) {
+	var rate Rate
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"net": "300.00",
+		"dailyRates": [
+			{"offset": 1, "net": "90.00", "selling": "100.00"},
+			{"offset": 2, "net": "100.00", "selling": "110.00"},
+			{"offset": 3, "net": "110.00", "selling": "120.00"}
+		]
+	}`), &rate))
+	assert.Equal(t, "300.00", decimal.Decimal(rate.TotalFromDailyRates()).StringFixed(2))
+	assert.Equal(t, "100.00", decimal.Decimal(rate.AverageNightlyNet()).StringFixed(2))
+	assert.NoError(t, rate.VerifyDailyRates("EUR"))
+
+	rate.DailyRates[2].Net = Amount(decimal.RequireFromString("111.00"))
+	assert.Equal(t, "100.33", decimal.Decimal(rate.AverageNightlyNet()).StringFixed(2))
+	assert.EqualError(t, rate.VerifyDailyRates("EUR"), "net mismatch: reported 300.00, sum of daily rates 301.00")
+	assert.EqualError(t, rate.VerifyDailyRates("JPY"), "net mismatch: reported 300, sum of daily rates 301")
+
+	rate.DailyRates = nil
+	assert.True(t, decimal.Decimal(rate.AverageNightlyNet()).IsZero())
+	assert.NoError(t, rate.VerifyDailyRates("EUR"))
+}
+
+func TestBreakDownTotalDiscount(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-confirm-booking.json")
+	assert.NoError(t, err)
+	var booking ConfirmBookingResponse
+	assert.NoError(t, json.Unmarshal(data, &booking))
+
+	breakDown := booking.Booking.Hotel.Rooms[0].Rates[0].BreakDown
+	assert.NotNil(t, breakDown)
+	assert.Equal(t, "-99.91", decimal.Decimal(breakDown.TotalDiscount()).StringFixed(2))
+
+	data, err = os.ReadFile("fixtures/200-list-checkrates-rate-discounts.json")
+	assert.NoError(t, err)
+	var checkRates ListCheckRatesResponse
+	assert.NoError(t, json.Unmarshal(data, &checkRates))
+
+	breakDown = checkRates.Hotel.Rooms[0].Rates[0].BreakDown
+	assert.NotNil(t, breakDown)
+	assert.Equal(t, 2, len(breakDown.Discounts))
+	assert.Equal(t, "-30.50", decimal.Decimal(breakDown.TotalDiscount()).StringFixed(2))
+}
+
+func TestListAvailableHotelsAccommodations(t *testing.T) {
+	inp := &ListAvailableHotelsInput{
+		Stay: Stay{
+			CheckIn:  "2024-04-02",
+			CheckOut: "2024-04-03",
+		},
+		Occupancies:    []Occupancy{{Rooms: 1, Adults: 2}},
+		Accommodations: []string{"HOTEL", "APARTMENT"},
+	}
+	assert.NoError(t, inp.Validate())
+
+	data, err := json.Marshal(inp)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"accommodations":["HOTEL","APARTMENT"]`)
+
+	inp.Accommodations = append(inp.Accommodations, "")
+	assert.Equal(t, ValidationErrors{{FieldName: "Accommodations", Required: true}}, inp.Validate())
+}
+
+func TestBookingRoomModificationPolicy(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-confirm-booking-room-policies.json")
+	assert.NoError(t, err)
+
+	var resp ConfirmBookingResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	rooms := resp.Booking.Hotel.Rooms
+	assert.Equal(t, 2, len(rooms))
+	assert.Equal(t, &ModificationPolicy{IsCancellationAllowed: true, IsModificationAllowed: true}, rooms[0].ModificationPolicy)
+	assert.Equal(t, &ModificationPolicy{IsCancellationAllowed: true, IsModificationAllowed: false}, rooms[1].ModificationPolicy)
+	assert.NoError(t, resp.Booking.Hotel.VerifyTotals())
+}
+
+func TestListAvailableHotelsSummary(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-list-available-hotels-multi.json")
+	assert.NoError(t, err)
+
+	var resp ListAvailableHotelsResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	summary := resp.Summary()
+	assert.Equal(t, 3, summary.TotalHotels)
+	assert.Equal(t, "150.10", decimal.Decimal(summary.MinRate).StringFixed(2))
+	assert.Equal(t, "640.90", decimal.Decimal(summary.MaxRate).StringFixed(2))
+	assert.Equal(t, "180.83", decimal.Decimal(summary.AverageRate).StringFixed(2))
+	assert.True(t, summary.MixedCurrencies)
+	assert.Empty(t, summary.Currency)
+
+	resp.Hotels.Hotels = resp.Hotels.Hotels[:2]
+	summary = resp.Summary()
+	assert.Equal(t, 2, summary.TotalHotels)
+	assert.Equal(t, "EUR", summary.Currency)
+	assert.False(t, summary.MixedCurrencies)
+
+	assert.Equal(t, AvailabilitySummary{}, (&ListAvailableHotelsResponse{}).Summary())
+}
+
+func TestListCheckRatesExpand(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.0/checkrates").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return false, err
+			}
+			return strings.Contains(string(body), `"expandCXL":true,"expandRateComments":true,"expandBoard":true`), nil
+		}).
+		Reply(200).
+		File("fixtures/200-list-checkrates-expanded.json")
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	resp, err := client.ListCheckRates(context.TODO(), &ListCheckRatesInput{
+		ExpandCXL:          true,
+		ExpandRateComments: true,
+		ExpandBoard:        true,
+		Rooms: []ListCheckRatesRoom{
+			{
+				RateKey: "20240402|20240403|W|164|6619|TWN.ST|BAR BB FLEX 14|BB||1~1~0||N@06~~21e12c~1630615603~S~~~NOR~5F05A4B7D40E44A170871765642600AADE00000010000000006248118",
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+
+	rate := resp.Hotel.Rooms[0].Rates[0]
+	assert.Equal(t, 2, len(rate.RateCommentsDetails))
+	assert.Equal(t, "Minimum check-in age 18.", rate.RateCommentsDetails[1].Description)
+	assert.NotNil(t, rate.Board)
+	assert.Equal(t, "BB", rate.Board.Code)
+	assert.Equal(t, "BED AND BREAKFAST", rate.Board.Description.Content)
+
+	data, err := json.Marshal(&ListCheckRatesInput{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "expandRateComments")
+	assert.NotContains(t, string(data), "expandBoard")
+}
+
+func TestCountBookings(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-api/1.0/bookings").
+		MatchParams(map[string]string{
+			"filterType": "CREATION",
+			"start":      "2024-02-01",
+			"end":        "2024-02-29",
+			"from":       "1",
+			"to":         "1",
+		}).
+		Reply(200).
+		File("fixtures/200-list-bookings-count.json")
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	count, err := client.(*API).CountBookings(context.TODO(), &ListBookingsInput{
+		ListInput:   ListInput{From: 1, To: 100},
+		FilterType:  "CREATION",
+		FilterStart: Datetime(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)),
+		FilterEnd:   Datetime(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, count)
+	assert.True(t, gock.IsDone())
+}
+
+func TestListAvailableHotelsBestShiftRate(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-list-available-hotels-shift-rates.json")
+	assert.NoError(t, err)
+
+	var resp ListAvailableHotelsResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	alt, ok := resp.BestShiftRate()
+	assert.True(t, ok)
+	assert.Equal(t, 6619, alt.HotelCode)
+	assert.Equal(t, "DBL.ST", alt.RoomCode)
+	assert.Equal(t, "2024-04-03", alt.Rate.CheckIn.String())
+	assert.Equal(t, "2024-04-04", alt.Rate.CheckOut.String())
+	assert.Equal(t, "231.10", decimal.Decimal(alt.Rate.Net).StringFixed(2))
+
+	_, ok = (&ListAvailableHotelsResponse{}).BestShiftRate()
+	assert.False(t, ok)
+}
+
+func TestRateBoardDescription(t *testing.T) {
+	boards := []Board{
+		{Code: "BB", Description: Content{Content: "BED AND BREAKFAST"}},
+		{Code: "RO", Description: Content{Content: "ROOM ONLY"}},
+	}
+
+	rate := Rate{BoardCode: "RO", BoardName: "Room only"}
+	assert.Equal(t, "ROOM ONLY", rate.BoardDescription(boards))
+
+	rate = Rate{BoardCode: "AI", BoardName: "ALL INCLUSIVE"}
+	assert.Equal(t, "ALL INCLUSIVE", rate.BoardDescription(boards))
+	assert.Equal(t, "ALL INCLUSIVE", rate.BoardDescription(nil))
+}
+
+func TestBookingSupplierReferences(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-confirm-booking-room-policies.json")
+	assert.NoError(t, err)
+
+	var resp ConfirmBookingResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+	assert.Equal(t, []string{"HB-5832991", "HB-5832992"}, resp.Booking.SupplierReferences())
+
+	booking := &Booking{Hotel: BookingHotel{Rooms: []BookingRoom{
+		{SupplierReference: "HB-1"},
+		{},
+		{SupplierReference: "HB-1"},
+	}}}
+	assert.Equal(t, []string{"HB-1"}, booking.SupplierReferences())
+	assert.Nil(t, (&Booking{}).SupplierReferences())
+}
+
+func TestGetHotelWithAvailability(t *testing.T) {
+	stay := Stay{CheckIn: "2024-04-02", CheckOut: "2024-04-03"}
+	occupancies := []Occupancy{{Rooms: 1, Adults: 1}}
+
+	t.Run("merged", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("https://api.test.hotelbeds.com").
+			Get("/hotel-content-api/1.0/hotels/6619/details").
+			Reply(200).
+			File("fixtures/200-get-hotel-details.json")
+		gock.New("https://api.test.hotelbeds.com").
+			Post("/hotel-api/1.0/hotels").
+			Reply(200).
+			File("fixtures/200-list-available-hotels.json")
+
+		client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+		resp, err := client.(*API).GetHotelWithAvailability(context.TODO(), 6619, stay, occupancies)
+		assert.NoError(t, err)
+		assert.NoError(t, resp.ContentErr)
+		assert.NoError(t, resp.AvailabilityErr)
+		assert.Equal(t, 6619, resp.Hotel.Code)
+		assert.Equal(t, 6619, resp.Availability.Code)
+		assert.NotEmpty(t, resp.Availability.Rooms)
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("https://api.test.hotelbeds.com").
+			Get("/hotel-content-api/1.0/hotels/6619/details").
+			Reply(200).
+			File("fixtures/200-get-hotel-details.json")
+		gock.New("https://api.test.hotelbeds.com").
+			Post("/hotel-api/1.0/hotels").
+			Reply(500).
+			JSON(map[string]string{"error": "System error"})
+
+		client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+		resp, err := client.(*API).GetHotelWithAvailability(context.TODO(), 6619, stay, occupancies)
+		assert.NoError(t, err)
+		assert.NoError(t, resp.ContentErr)
+		assert.Equal(t, 6619, resp.Hotel.Code)
+		assert.Nil(t, resp.Availability)
+		var apiErr *Error
+		assert.ErrorAs(t, resp.AvailabilityErr, &apiErr)
+		assert.True(t, apiErr.IsServerError())
+	})
+
+	t.Run("both failed", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("https://api.test.hotelbeds.com").
+			Get("/hotel-content-api/1.0/hotels/6619/details").
+			Reply(500).
+			JSON(map[string]string{"error": "System error"})
+		gock.New("https://api.test.hotelbeds.com").
+			Post("/hotel-api/1.0/hotels").
+			Reply(500).
+			JSON(map[string]string{"error": "System error"})
+
+		client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+		resp, err := client.(*API).GetHotelWithAvailability(context.TODO(), 6619, stay, occupancies)
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.ErrorContains(t, err, "failed to get hotel content")
+		var apiErr *Error
+		assert.ErrorAs(t, err, &apiErr)
+		assert.True(t, apiErr.IsServerError())
+	})
+}
+
+func TestRecheckBooking(t *testing.T) {
+	defer gock.Off()
+
+	data, err := os.ReadFile("fixtures/200-get-booking-recheck.json")
+	assert.NoError(t, err)
+	var booking GetBookingResponse
+	assert.NoError(t, json.Unmarshal(data, &booking))
+	// Booking fixture is priced in GBP, checkrates fixture in EUR.
+	booking.Booking.Hotel.Currency = "EUR"
+
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.0/checkrates").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return false, err
+			}
+			return strings.Contains(string(body), `"rateKey":"20240406|20240408|W|506|712986|DBL.DX|ID_B2B_26|BB||1~2~0||`), nil
+		}).
+		Reply(200).
+		File("fixtures/200-list-checkrates.json")
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	result, err := client.(*API).RecheckBooking(context.TODO(), booking.Booking)
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+	assert.NotNil(t, result.CheckRates)
+	assert.True(t, result.PriceChanged)
+	assert.Equal(t, "899.23", decimal.Decimal(result.PreviousTotalNet).StringFixed(2))
+	assert.Equal(t, "280.72", decimal.Decimal(result.CurrentTotalNet).StringFixed(2))
+
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.0/checkrates").
+		Reply(200).
+		JSON(map[string]any{"hotel": map[string]any{"totalNet": 899.23}})
+
+	result, err = client.(*API).RecheckBooking(context.TODO(), booking.Booking)
+	assert.NoError(t, err)
+	assert.False(t, result.PriceChanged)
+
+	// Prices in different currencies aren't compared.
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.0/checkrates").
+		Reply(200).
+		JSON(map[string]any{"hotel": map[string]any{"totalNet": 899.23, "currency": "USD"}})
+
+	_, err = client.(*API).RecheckBooking(context.TODO(), booking.Booking)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	assert.EqualError(t, err, "currency mismatch: EUR and USD")
+
+	// Rates are no longer available.
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.0/checkrates").
+		Reply(200).
+		JSON(map[string]any{"auditData": map[string]any{"processTime": "12"}})
+
+	result, err = client.(*API).RecheckBooking(context.TODO(), booking.Booking)
+	assert.NoError(t, err)
+	assert.True(t, result.Unavailable)
+	assert.False(t, result.PriceChanged)
+	assert.True(t, decimal.Decimal(result.CurrentTotalNet).IsZero())
+
+	_, err = client.(*API).RecheckBooking(context.TODO(), &Booking{})
+	assert.EqualError(t, err, "booking has no rate keys")
+}
+
+func TestAvailableHotelMinMaxMoney(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-list-available-hotels.json")
+	assert.NoError(t, err)
+
+	var resp ListAvailableHotelsResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	hotel := resp.Hotels.Hotels[0]
+	min, max := hotel.MinMoney(), hotel.MaxMoney()
+	assert.Equal(t, "212.40", decimal.Decimal(min.Amount).StringFixed(2))
+	assert.Equal(t, "EUR", min.Currency)
+	assert.Equal(t, "525.43", decimal.Decimal(max.Amount).StringFixed(2))
+	assert.Equal(t, "EUR", max.Currency)
+	// Sum has no float precision error.
+	assert.Equal(t, "737.83", decimal.Decimal(min.Amount).Add(decimal.Decimal(max.Amount)).String())
+}
+
+func TestCheckRateHotelTotalMoney(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-list-checkrates.json")
+	assert.NoError(t, err)
+	var resp ListCheckRatesResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	total := resp.Hotel.TotalMoney()
+	assert.Equal(t, "280.72", decimal.Decimal(total.Amount).StringFixed(2))
+	assert.Equal(t, "EUR", total.Currency)
+
+	equal, err := total.Equal(Money{Amount: Amount(decimal.RequireFromString("280.72")), Currency: "EUR"})
+	assert.NoError(t, err)
+	assert.True(t, equal)
+
+	equal, err = total.Equal(Money{Amount: Amount(decimal.RequireFromString("280.73"))})
+	assert.NoError(t, err)
+	assert.False(t, equal)
+
+	_, err = total.Equal(Money{Amount: total.Amount, Currency: "GBP"})
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestMoneyMarshalJSON(t *testing.T) {
+	amount := Amount(decimal.RequireFromString("1500.4567"))
+	for currency, want := range map[string]string{
+		"JPY": `{"amount":1500,"currency":"JPY"}`,
+		"KWD": `{"amount":1500.457,"currency":"KWD"}`,
+		"EUR": `{"amount":1500.46,"currency":"EUR"}`,
+		"":    `{"amount":1500.46,"currency":""}`,
+	} {
+		data, err := json.Marshal(Money{Amount: amount, Currency: currency})
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(data), currency)
+
+		var money Money
+		assert.NoError(t, json.Unmarshal(data, &money))
+		assert.Equal(t, currency, money.Currency)
+		assert.Equal(t, amount.StringCurrency(currency), decimal.Decimal(money.Amount).String())
+	}
+}
+
+func TestListAvailableHotelsRateComments(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-list-available-hotels-rate-comments.json")
+	assert.NoError(t, err)
+
+	var resp ListAvailableHotelsResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	rates := resp.Hotels.Hotels[0].Rooms[0].Rates
+	assert.Equal(t, "Car park YES (with additional debit notes). Check-in hour 15:00 - 23:00.", rates[0].RateComments)
+	assert.Equal(t, "", rates[1].RateComments)
+}
+
+func TestListAvailableHotelsRoomUpselling(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-list-available-hotels-upselling.json")
+	assert.NoError(t, err)
+
+	var resp ListAvailableHotelsResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	room := resp.Hotels.Hotels[0].Rooms[0]
+	assert.Equal(t, 1, len(room.Rates))
+	assert.Equal(t, 1, len(room.Upselling))
+
+	upselling := room.Upselling[0]
+	assert.Equal(t, "BB", upselling.BoardCode)
+	assert.Equal(t, "251.01", decimal.Decimal(upselling.Net).StringFixed(2))
+	assert.Equal(t, "12.50", decimal.Decimal(upselling.Discount).StringFixed(2))
+	assert.Equal(t, 4.75, upselling.DiscountPercent.Float())
+	assert.True(t, upselling.HotelMandatory)
+	assert.Equal(t, "25.10", decimal.Decimal(upselling.Comission).StringFixed(2))
+	assert.True(t, decimal.Decimal(upselling.ComissionVAT).IsZero())
+	assert.Equal(t, 10.0, upselling.ComissionPercent.Float())
+	assert.Equal(t, "15.00", decimal.Decimal(upselling.Rateup).StringFixed(2))
+	assert.Equal(t, "HOTELBEDS", upselling.Brand)
+}
+
+func TestConfirmBookingInputValidate(t *testing.T) {
+	inp := &ConfirmBookingInput{
+		Holder:          Holder{Name: "HolderFirstName"},
+		ClientReference: "IntegrationAgencyReference",
+		Rooms: []ConfirmBookingRoom{
+			{RateKey: "20240402|20240403|W|164|6619|TWN.ST|BAR BB FLEX 14|BB||1~1~0||"},
+			{},
+		},
+	}
+	assert.Equal(t, ValidationErrors{
+		{FieldName: "Holder.Surname", Required: true},
+		{FieldName: "ClientReference", Required: true, Max: 20},
+		{FieldName: "Rooms.RateKey", Required: true},
+	}, inp.Validate())
+	assert.EqualError(t, inp.Validate(), "field=Holder.Surname,required=true,min=0,max=0,allow=[]; "+
+		"field=ClientReference,required=true,min=0,max=20,allow=[]; "+
+		"field=Rooms.RateKey,required=true,min=0,max=0,allow=[]")
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	_, err := client.ConfirmBooking(context.TODO(), inp)
+	assert.IsType(t, ValidationErrors{}, err)
+
+	inp.Holder.Surname = "HolderLastName"
+	inp.ClientReference = "IntegrationAgency"
+	inp.Rooms = inp.Rooms[:1]
+	assert.NoError(t, inp.Validate())
+}
+
+func TestConfirmBookingPackagingRate(t *testing.T) {
+	var resp ListAvailableHotelsResponse
+	assert.NoError(t, json.Unmarshal([]byte(`{"hotels": {"hotels": [{"code": 164, "rooms": [{"code": "TWN.ST", "rates": [
+		{"rateKey": "20240402|20240403|W|164|6619|TWN.ST|BAR BB FLEX 14|BB||1~1~0||", "packaging": false},
+		{"rateKey": "20240402|20240403|W|164|6619|TWN.ST|PAQ BB|BB||1~1~0||", "packaging": true}
+	]}]}]}}`), &resp))
+	rates := resp.Hotels.Hotels[0].Rooms[0].Rates
+	assert.False(t, rates[0].RequiresPackage())
+	assert.True(t, rates[1].RequiresPackage())
+
+	inp := &ConfirmBookingInput{
+		Holder:          Holder{Name: "HolderFirstName", Surname: "HolderLastName"},
+		ClientReference: "IntegrationAgency",
+	}
+	for _, rate := range rates {
+		inp.Rooms = []ConfirmBookingRoom{{RateKey: rate.RateKey, Packaging: rate.RequiresPackage()}}
+		if rate.RequiresPackage() {
+			assert.Equal(t, ValidationErrors{{FieldName: "Rooms.Packaging", Allow: []string{"false"}}}, inp.Validate())
+		} else {
+			assert.NoError(t, inp.Validate())
+		}
+	}
+
+	data, err := json.Marshal(inp)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "packaging")
+}
+
+func TestConfirmBookingPaxRooms(t *testing.T) {
+	adult := func(roomID int) Pax {
+		return Pax{Type: PaxTypeAdult, Name: "Adult", Surname: "Pax", RoomID: roomID}
+	}
+	child := func(roomID int) Pax {
+		return Pax{Type: PaxTypeChildren, Age: 8, Name: "Child", Surname: "Pax", RoomID: roomID}
+	}
+	inp := &ConfirmBookingInput{
+		Holder:          Holder{Name: "HolderFirstName", Surname: "HolderLastName"},
+		ClientReference: "IntegrationAgency",
+		Rooms: []ConfirmBookingRoom{
+			{
+				RateKey: "20240402|20240403|W|164|6619|DBL.ST|BAR RO|RO||2~1~1|8|",
+				Paxes:   []Pax{adult(1), child(1), adult(2), child(2)},
+			},
+			{
+				RateKey: "20240402|20240403|W|164|6619|TWN.ST|BAR BB|BB||1~1~0||",
+				Paxes:   []Pax{adult(0)},
+			},
+		},
+	}
+	assert.NoError(t, inp.Validate())
+
+	// Pax of the non-existent room, the second room has a child only.
+	inp.Rooms[0].Paxes = []Pax{adult(1), child(2), adult(3)}
+	// Room without paxes.
+	inp.Rooms[1].Paxes = nil
+	assert.Equal(t, ValidationErrors{
+		{FieldName: "Rooms.Paxes.RoomID", Min: 1, Max: 2},
+		{FieldName: "Rooms.Paxes.Adult", Required: true},
+		{FieldName: "Rooms.Paxes.Adult", Required: true},
+	}, inp.Validate())
+
+	// Assignment isn't validated if paxes aren't specified at all.
+	inp.Rooms[0].Paxes = nil
+	assert.NoError(t, inp.Validate())
+}
+
+func TestNewConfirmBookingRoom(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-list-checkrates.json")
+	assert.NoError(t, err)
+	var resp ListCheckRatesResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+	rate := resp.Hotel.Rooms[0].Rates[0]
+	rate.Rooms, rate.Adults, rate.Children = 1, 2, 1
+
+	paxes := []Pax{
+		{Type: PaxTypeAdult, Name: "First", Surname: "Adult"},
+		{Type: PaxTypeAdult, Name: "Second", Surname: "Adult"},
+		{Type: PaxTypeChildren, Age: 8, Name: "First", Surname: "Child"},
+	}
+	room, err := NewConfirmBookingRoom(rate, paxes)
+	assert.NoError(t, err)
+	assert.Equal(t, rate.RateKey, room.RateKey)
+	assert.Equal(t, paxes, room.Paxes)
+
+	_, err = NewConfirmBookingRoom(rate, paxes[:2])
+	assert.Equal(t, ValidationErrors{{FieldName: "Paxes.Children", Min: 1, Max: 1}}, err)
+
+	rate.Rooms = 2
+	_, err = NewConfirmBookingRoom(rate, paxes)
+	assert.Equal(t, ValidationErrors{
+		{FieldName: "Paxes.Adults", Min: 4, Max: 4},
+		{FieldName: "Paxes.Children", Min: 2, Max: 2},
+	}, err)
+}
+
+func TestValidateRateKey(t *testing.T) {
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	rateKey := "20240402|20240403|W|164|6619|TWN.ST|BAR BB FLEX 14|BB||1~1~0||N@06~~21e12c~1630615603~S~~~NOR~5F05A4B7D40E44A170871765642600AADE00000010000000006248118"
+	stay := Stay{CheckIn: "2024-04-02", CheckOut: "2024-04-03"}
+	assert.NoError(t, client.(*API).ValidateRateKey(rateKey, 6619, stay))
+
+	err := client.(*API).ValidateRateKey(rateKey, 712986, stay)
+	assert.ErrorIs(t, err, ErrRateKeyMismatch)
+	assert.EqualError(t, err, "rateKey mismatch: hotel code 6619, expected 712986")
+
+	err = client.(*API).ValidateRateKey(rateKey, 6619, Stay{CheckIn: "2024-04-01", CheckOut: "2024-04-03"})
+	assert.EqualError(t, err, "rateKey mismatch: check-in 2024-04-02, expected 2024-04-01")
+
+	err = client.(*API).ValidateRateKey(rateKey, 6619, Stay{CheckIn: "2024-04-02", CheckOut: "2024-04-05"})
+	assert.EqualError(t, err, "rateKey mismatch: check-out 2024-04-03, expected 2024-04-05")
+
+	assert.ErrorIs(t, client.(*API).ValidateRateKey("invalid", 6619, stay), ErrInvalidRateKey)
+}
+
+func TestSplitBookingByRoomLimit(t *testing.T) {
+	inp := &ConfirmBookingInput{
+		Holder:          Holder{Name: "HolderFirstName", Surname: "HolderLastName"},
+		ClientReference: "IntegrationAgency",
+		Remark:          "Late arrival",
+	}
+	for i := 0; i < 20; i++ {
+		inp.Rooms = append(inp.Rooms, ConfirmBookingRoom{RateKey: fmt.Sprintf("20240402|20240403|W|164|6619|DBL.ST|BAR RO|RO||1~2~0||%d", i)})
+	}
+	assert.Equal(t, ValidationErrors{{FieldName: "Rooms", Required: true, Max: 9}}, inp.Validate())
+
+	inps, err := SplitBookingByRoomLimit(inp, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(inps))
+	var rateKeys []string
+	for i, part := range inps {
+		assert.NoError(t, part.Validate())
+		assert.Equal(t, inp.Holder, part.Holder)
+		assert.Equal(t, []string{"IntegrationAgency-1", "IntegrationAgency-2", "IntegrationAgency-3"}[i], part.ClientReference)
+		assert.Equal(t, "Late arrival", part.Remark)
+		assert.Equal(t, []int{9, 9, 2}[i], len(part.Rooms))
+		for _, room := range part.Rooms {
+			rateKeys = append(rateKeys, room.RateKey)
+		}
+	}
+	for i, room := range inp.Rooms {
+		assert.Equal(t, room.RateKey, rateKeys[i])
+	}
+	assert.Equal(t, 20, len(inp.Rooms))
+	assert.Equal(t, "IntegrationAgency", inp.ClientReference)
+
+	inps, err = SplitBookingByRoomLimit(inp, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(inps))
+	assert.Equal(t, "IntegrationAgency-4", inps[3].ClientReference)
+	assert.Equal(t, 5, len(inps[3].Rooms))
+
+	// Suffixed reference doesn't fit the limit.
+	inp.ClientReference = "IntegrationAgencyRef"
+	_, err = SplitBookingByRoomLimit(inp, 0)
+	assert.Equal(t, ValidationErrors{{FieldName: "ClientReference", Required: true, Max: 18}}, err)
+
+	inp.Rooms = inp.Rooms[:9]
+	inps, err = SplitBookingByRoomLimit(inp, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []*ConfirmBookingInput{inp}, inps)
+}
+
+func TestListAvailableHotelsInputValidateAggregates(t *testing.T) {
+	inp := &ListAvailableHotelsInput{
+		Stay:           Stay{CheckIn: "2024-04-02", CheckOut: "2024-04-03", ShiftDays: 7},
+		Filter:         &Filter{MaxHotels: 10, MaxRooms: 100, MinCategory: 1, MaxCategory: 5},
+		Rooms:          &FilterRooms{},
+		Accommodations: []string{"", ""},
+	}
+	assert.Equal(t, ValidationErrors{
+		{FieldName: "ShiftDays", Max: 5},
+		{FieldName: "Occupancies", Required: true},
+		{FieldName: "MaxRooms", Min: 1, Max: 50},
+		{FieldName: "Accommodations", Required: true},
+		{FieldName: "FilterRooms.Room", Required: true},
+	}, inp.Validate())
+}
+
+func TestListAvailableHotelsInputValidateMaxRooms(t *testing.T) {
+	inp := &ListAvailableHotelsInput{
+		Stay:        Stay{CheckIn: "2024-04-02", CheckOut: "2024-04-03"},
+		Occupancies: []Occupancy{{Rooms: 2, Adults: 2}, {Rooms: 1, Adults: 1}},
+		Filter:      &Filter{MaxHotels: 10, MaxRooms: 2, MinCategory: 1, MaxCategory: 5},
+	}
+	assert.Equal(t, ValidationErrors{{FieldName: "MaxRooms", Min: 3, Max: 50}}, inp.Validate())
+
+	inp.Filter.MaxRooms = 3
+	assert.NoError(t, inp.Validate())
+}
+
+func TestListAvailableHotelsInputValidateGraph(t *testing.T) {
+	latitude := 39.57
+	inp := &ListAvailableHotelsInput{
+		Stay:           Stay{CheckIn: "2024-04-02", CheckOut: "2024-04-03"},
+		Keywords:       []Keyword{{AllIncluded: true}},
+		Geolocation:    &Geolocation{Latitude: &latitude, Radius: 300},
+		Boards:         &FilterBoards{Boards: []string{"BB", " "}},
+		Rooms:          &FilterRooms{Codes: []string{""}},
+		BoardGroups:    &FilterBoardGroups{Codes: []string{"AI", ""}},
+		Accommodations: []string{"HOTEL", ""},
+	}
+	err := inp.Validate()
+	var errs ValidationErrors
+	assert.ErrorAs(t, err, &errs)
+	fields := make([]string, len(errs))
+	for i := range errs {
+		fields[i] = errs[i].FieldName
+	}
+	assert.ElementsMatch(t, []string{
+		"Occupancies",
+		"Keyword.Keywords",
+		"Longitude",
+		"Radius",
+		"FilterBoards.Boards",
+		"FilterRooms.Room",
+		"FilterBoardGroups.BoardGroup",
+		"Accommodations",
+	}, fields)
+
+	inp.Occupancies = []Occupancy{{Rooms: 1, Adults: 2}}
+	inp.Keywords[0].Keywords = []int{1}
+	inp.Geolocation.Longitude, inp.Geolocation.Radius = &latitude, 20
+	inp.Boards.Boards = []string{"BB"}
+	inp.Rooms.Codes = []string{"DBL.ST"}
+	inp.BoardGroups.Codes = []string{"AI"}
+	inp.Accommodations = []string{"HOTEL"}
+	assert.NoError(t, inp.Validate())
+}
+
+func TestGetBookings(t *testing.T) {
+	defer gock.Off()
+
+	for _, id := range []string{"207-12306403", "207-12306404"} {
+		gock.New("https://api.test.hotelbeds.com").
+			Get("/hotel-api/1.0/bookings/" + id).
+			Reply(200).
+			JSON(map[string]any{"booking": map[string]any{"reference": id, "status": BookingStatusConfirmed}})
+	}
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-api/1.0/bookings/207-00000000").
+		Reply(400).
+		JSON(map[string]any{"error": map[string]any{"code": ErrorCodeInvalidData, "message": "Booking does not exist"}})
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	bookings, errs := client.(*API).GetBookings(context.TODO(), []string{"207-12306403", "207-00000000", "207-12306404", "207-12306403"})
+	assert.Equal(t, 2, len(bookings))
+	assert.Equal(t, "207-12306403", bookings["207-12306403"].Reference)
+	assert.Equal(t, "207-12306404", bookings["207-12306404"].Reference)
+	assert.Equal(t, 1, len(errs))
+	assert.True(t, IsErrorCode(errs["207-00000000"], ErrorCodeInvalidData))
+	assert.True(t, gock.IsDone())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ids := make([]string, 2*getBookingsConcurrency)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("207-%08d", i)
+	}
+	bookings, errs = client.(*API).GetBookings(ctx, ids)
+	assert.Empty(t, bookings)
+	assert.Equal(t, len(ids), len(errs))
+	for _, id := range ids {
+		assert.ErrorIs(t, errs[id], context.Canceled)
+	}
+}
+
+func TestSafeConfirmBookingRetry(t *testing.T) {
+	inp := &ConfirmBookingInput{
+		Holder:          Holder{Name: "HolderFirstName", Surname: "HolderLastName"},
+		ClientReference: "IntegrationAgency",
+		Rooms: []ConfirmBookingRoom{
+			{RateKey: "20240406|20240408|W|506|712986|DBL.DX|ID_B2B_26|BB||1~2~0||"},
+		},
+	}
+	newClient := func() Client {
+		return New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"),
+			WithRetry(3, time.Millisecond, 5*time.Millisecond, nil, SafeConfirmBookingRetry(func(resp *http.Response, err error) bool {
+				return err != nil || resp.StatusCode >= 500
+			})))
+	}
+
+	t.Run("server error", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("https://api.test.hotelbeds.com").
+			Post("/hotel-api/1.2/bookings").
+			Reply(500).
+			JSON(map[string]string{"error": "System error"})
+		gock.New("https://api.test.hotelbeds.com").
+			Post("/hotel-api/1.2/bookings").
+			Reply(200).
+			File("fixtures/200-confirm-booking.json")
+
+		_, err := newClient().ConfirmBooking(context.TODO(), inp)
+		var apiErr *Error
+		assert.ErrorAs(t, err, &apiErr)
+		assert.True(t, apiErr.IsServerError())
+		// Booking might be confirmed despite the error.
+		assert.False(t, IsErrorRetryable(err))
+		assert.True(t, gock.IsPending())
+	})
+
+	t.Run("dial error", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("https://api.test.hotelbeds.com").
+			Post("/hotel-api/1.2/bookings").
+			ReplyError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+		gock.New("https://api.test.hotelbeds.com").
+			Post("/hotel-api/1.2/bookings").
+			Reply(200).
+			File("fixtures/200-confirm-booking.json")
+
+		resp, err := newClient().ConfirmBooking(context.TODO(), inp)
+		assert.NoError(t, err)
+		assert.Equal(t, "207-12306403", resp.Booking.Reference)
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("other requests", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("https://api.test.hotelbeds.com").
+			Get("/hotel-content-api/1.0/types/boards").
+			Reply(500).
+			JSON(map[string]string{"error": "System error"})
+		gock.New("https://api.test.hotelbeds.com").
+			Get("/hotel-content-api/1.0/types/boards").
+			Reply(200).
+			File("fixtures/200-list-types-boards.json")
+
+		_, err := newClient().ListBoards(context.TODO(), &ListBoardsInput{})
+		assert.NoError(t, err)
+		assert.True(t, gock.IsDone())
+	})
+}
+
+func TestConfirmBookingAndWait(t *testing.T) {
+	defer gock.Off()
+	defer func(interval time.Duration) { bookingPollInterval = interval }(bookingPollInterval)
+	bookingPollInterval = time.Millisecond
+
+	booking := func(status BookingStatus) map[string]any {
+		return map[string]any{"booking": map[string]any{"reference": "207-12306403", "status": status}}
+	}
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.2/bookings").
+		Reply(200).
+		JSON(booking(BookingStatusPending))
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-api/1.0/bookings/207-12306403").
+		Times(2).
+		Reply(200).
+		JSON(booking(BookingStatusPending))
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-api/1.0/bookings/207-12306403").
+		Reply(200).
+		File("fixtures/200-confirm-booking.json")
+
+	inp := &ConfirmBookingInput{
+		Holder:          Holder{Name: "HolderFirstName", Surname: "HolderLastName"},
+		ClientReference: "IntegrationAgency",
+		Rooms: []ConfirmBookingRoom{
+			{RateKey: "20240402|20240403|W|164|6619|TWN.ST|BAR BB FLEX 14|BB||1~1~0||"},
+		},
+	}
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	resp, err := client.(*API).ConfirmBookingAndWait(context.TODO(), inp, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, BookingStatusConfirmed, resp.Booking.Status)
+	assert.Equal(t, 712986, resp.Booking.Hotel.Code)
+	assert.True(t, gock.IsDone())
+
+	// Booking is still pending when timeout expires.
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.2/bookings").
+		Reply(200).
+		JSON(booking(BookingStatusPending))
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-api/1.0/bookings/207-12306403").
+		Persist().
+		Reply(200).
+		JSON(booking(BookingStatusPending))
+
+	resp, err = client.(*API).ConfirmBookingAndWait(context.TODO(), inp, 20*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, BookingStatusPending, resp.Booking.Status)
+	assert.Equal(t, "207-12306403", resp.Booking.Reference)
+}
+
+func TestFilterBoardGroups(t *testing.T) {
+	inp := &ListAvailableHotelsInput{
+		Stay: Stay{
+			CheckIn:  "2024-04-02",
+			CheckOut: "2024-04-03",
+		},
+		Occupancies: []Occupancy{{Rooms: 1, Adults: 2}},
+		BoardGroups: &FilterBoardGroups{
+			Codes:    []string{"AI", "BB"},
+			Included: true,
+		},
+	}
+	assert.NoError(t, inp.Validate())
+
+	data, err := json.Marshal(inp)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"boardGroups":{"boardGroup":["AI","BB"],"included":true}`)
+
+	inp.BoardGroups.Codes = nil
+	assert.Equal(t, ValidationErrors{{FieldName: "FilterBoardGroups.BoardGroup", Required: true}}, inp.Validate())
+}
+
+func TestFilterSegments(t *testing.T) {
+	filter := &Filter{MaxHotels: 10, MaxRooms: 5, MinCategory: 1, MaxCategory: 5, Segments: []int{100, 102}}
+	assert.NoError(t, filter.Validate())
+
+	data, err := json.Marshal(filter)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"segments":[100,102]`)
+
+	data, err = os.ReadFile("fixtures/200-list-types-segments.json")
+	assert.NoError(t, err)
+	var segments ListSegmentsResponse
+	assert.NoError(t, json.Unmarshal(data, &segments))
+	assert.NoError(t, filter.ValidateSegments(segments.Segments))
+
+	filter.Segments = append(filter.Segments, 31)
+	assert.Equal(t, &ValidationError{FieldName: "Segments", Allow: []string{"100", "102"}}, filter.ValidateSegments(segments.Segments))
+
+	filter.Segments = []int{0}
+	assert.Equal(t, ValidationErrors{{FieldName: "Segments", Min: 1}}, filter.Validate())
+
+	filter.Segments = nil
+	data, err = json.Marshal(filter)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "segments")
+}
+
+func TestRateBoardGroup(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-list-types-boards.json")
+	assert.NoError(t, err)
+	var boards ListBoardsResponse
+	assert.NoError(t, json.Unmarshal(data, &boards))
+
+	data, err = os.ReadFile("fixtures/200-list-types-board-groups.json")
+	assert.NoError(t, err)
+	var groups ListBoardGroupsResponse
+	assert.NoError(t, json.Unmarshal(data, &groups))
+
+	assert.Equal(t, "AI", Rate{BoardCode: "AI"}.BoardGroup(boards.Boards, groups.Groups))
+	assert.Equal(t, "", Rate{BoardCode: "RO"}.BoardGroup(boards.Boards, groups.Groups))
+	assert.Equal(t, "", Rate{BoardCode: "AI"}.BoardGroup(nil, groups.Groups))
+
+	// Board code differs from the code of its group.
+	boards.Boards = append(boards.Boards, Board{Code: "CB", MultiLingualCode: "AB"})
+	assert.Equal(t, "AB", Rate{BoardCode: "CB"}.BoardGroup(boards.Boards, groups.Groups))
+	assert.Equal(t, "", Rate{BoardCode: "CB"}.BoardGroup(boards.Boards, groups.Groups[1:]))
+}
+
+func TestHolderContactFields(t *testing.T) {
+	inp := &ConfirmBookingInput{
+		Holder: Holder{
+			Name:        "HolderFirstName",
+			Surname:     "HolderLastName",
+			Title:       "MR",
+			Email:       "holder@example.com",
+			PhoneNumber: "+34971211100",
+		},
+	}
+	data, err := json.Marshal(inp)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"holder":{"name":"HolderFirstName","surname":"HolderLastName","title":"MR","email":"holder@example.com","phoneNumber":"+34971211100"}`)
+
+	inp.Holder = Holder{Name: "HolderFirstName", Surname: "HolderLastName"}
+	data, err = json.Marshal(inp)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"holder":{"name":"HolderFirstName","surname":"HolderLastName"}`)
+}
+
+func TestBookingMarshalStable(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-confirm-booking.json")
+	assert.NoError(t, err)
+	var resp ConfirmBookingResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	stored, err := resp.Booking.MarshalStable()
+	assert.NoError(t, err)
+	assert.Contains(t, string(stored), `"schemaVersion":1`)
+
+	booking, err := UnmarshalStableBooking(stored)
+	assert.NoError(t, err)
+	assert.Equal(t, resp.Booking.Reference, booking.Reference)
+	assert.Equal(t, resp.Booking.Hotel.Rooms[0].Rates[0].RateKey, booking.Hotel.Rooms[0].Rates[0].RateKey)
+	assert.Equal(t, decimal.Decimal(resp.Booking.TotalNet).StringFixed(2), decimal.Decimal(booking.TotalNet).StringFixed(2))
+
+	restored, err := booking.MarshalStable()
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(stored), string(restored))
+}
+
+func TestUnmarshalStableBookingOlderShape(t *testing.T) {
+	// Booking stored without the envelope, with a field which was removed
+	// since then and without fields which were added later.
+	booking, err := UnmarshalStableBooking([]byte(`{
+		"reference": "102-5832991",
+		"clientReference": "IntegrationAgency",
+		"status": "CONFIRMED",
+		"totalNet": "899.23",
+		"currency": "EUR",
+		"legacyAgencyCode": 1234,
+		"hotel": {
+			"code": 712986,
+			"rooms": [{"code": "DBL.DX", "rates": [{"rateClass": "nor", "net": 899.23}]}]
+		}
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "102-5832991", booking.Reference)
+	assert.Equal(t, BookingStatusConfirmed, booking.Status)
+	assert.Equal(t, 712986, booking.Hotel.Code)
+	assert.Equal(t, RateClassNormal, booking.Hotel.Rooms[0].Rates[0].RateClass)
+	assert.Empty(t, booking.Hotel.Upselling)
+
+	booking, err = UnmarshalStableBooking([]byte(`{"schemaVersion":2,"booking":{"reference":"102-5832991","newField":true}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "102-5832991", booking.Reference)
+
+	_, err = UnmarshalStableBooking([]byte(`{"schemaVersion":1,"booking":{"totalNet":"abc"}}`))
+	assert.ErrorContains(t, err, "failed to decode stored booking of version 1")
+}
+
+func TestCancellationPolicyHoursBefore(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-list-available-hotels-hours-before.json")
+	assert.NoError(t, err)
+	var resp ListAvailableHotelsResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	room := resp.Hotels.Hotels[0].Rooms[0]
+	policy := room.Rates[1].CancellationPolicies[0]
+	assert.True(t, time.Time(policy.From).IsZero())
+	assert.Equal(t, 48, *policy.HoursBefore)
+
+	checkIn := Datetime(time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), policy.Cutoff(checkIn))
+
+	// Non-refundable rate is penalized since 2024-03-03, the flexible one since 2024-03-31.
+	rate, ok := room.CheapestRefundable(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, "80.00", decimal.Decimal(rate.Net).StringFixed(2))
+
+	rate, ok = room.CheapestRefundable(time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, "95.00", decimal.Decimal(rate.Net).StringFixed(2))
+
+	// From takes precedence over HoursBefore.
+	from := time.Date(2024, 3, 25, 23, 59, 0, 0, time.UTC)
+	policy.From = TimestampTZ(from)
+	assert.Equal(t, from, policy.Cutoff(checkIn))
+}
+
+func TestBookingFreeCancellationUntil(t *testing.T) {
+	tiers := func(amounts []int64, froms ...time.Time) []CancellationPolicy {
+		policies := make([]CancellationPolicy, len(froms))
+		for i, from := range froms {
+			policies[i] = CancellationPolicy{Amount: Amount(decimal.NewFromInt(amounts[i])), From: TimestampTZ(from)}
+		}
+		return policies
+	}
+	cet := time.FixedZone("CET", 3600)
+
+	booking := &Booking{Hotel: BookingHotel{Rooms: []BookingRoom{
+		{Rates: []Rate{{CancellationPolicies: tiers([]int64{50, 100},
+			time.Date(2024, 3, 30, 23, 59, 0, 0, cet),
+			time.Date(2024, 4, 4, 23, 59, 0, 0, cet),
+		)}}},
+		{Rates: []Rate{{CancellationPolicies: tiers([]int64{100, 30},
+			time.Date(2024, 4, 5, 23, 59, 0, 0, cet),
+			time.Date(2024, 3, 26, 23, 59, 0, 0, cet),
+		)}}},
+		{Rates: []Rate{{}}},
+	}}}
+	until, ok := booking.FreeCancellationUntil()
+	assert.True(t, ok)
+	assert.True(t, until.Equal(time.Date(2024, 3, 26, 22, 59, 0, 0, time.UTC)))
+
+	data, err := os.ReadFile("fixtures/200-confirm-booking.json")
+	assert.NoError(t, err)
+	var resp ConfirmBookingResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+	until, ok = resp.Booking.FreeCancellationUntil()
+	assert.True(t, ok)
+	assert.Equal(t, "2024-03-26T23:59:00+01:00", until.Format(time.RFC3339))
+
+	_, ok = (&Booking{}).FreeCancellationUntil()
+	assert.False(t, ok)
+}
+
+func TestConfirmBookingInputRequirePaymentData(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-list-checkrates.json")
+	assert.NoError(t, err)
+	var checkRates ListCheckRatesResponse
+	assert.NoError(t, json.Unmarshal(data, &checkRates))
+
+	inp := &ConfirmBookingInput{
+		Holder:             Holder{Name: "HolderFirstName", Surname: "HolderLastName"},
+		ClientReference:    "IntegrationAgency",
+		Rooms:              []ConfirmBookingRoom{{RateKey: checkRates.Hotel.Rooms[0].Rates[0].RateKey}},
+		RequirePaymentData: checkRates.Hotel.PaymentDataRequired,
+	}
+	assert.False(t, inp.RequirePaymentData)
+	assert.NoError(t, inp.Validate())
+
+	inp.RequirePaymentData = true
+	assert.Equal(t, ValidationErrors{{FieldName: "Payment", Required: true}}, inp.Validate())
+
+	inp.Payment = &PaymentData{}
+	assert.NoError(t, inp.Validate())
+
+	data, err = json.Marshal(inp)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "RequirePaymentData")
+}
+
+func TestSameOccupancyRooms(t *testing.T) {
+	assert.Equal(t, []Occupancy{{Rooms: 1, Adults: 2}}, SameOccupancyRooms(1, 2))
+	assert.Equal(t, []Occupancy{{
+		Rooms:    5,
+		Adults:   2,
+		Children: 2,
+		Paxes: []Pax{
+			{Type: PaxTypeChildren, Age: 4},
+			{Type: PaxTypeChildren, Age: 11},
+		},
+	}}, SameOccupancyRooms(5, 2, 4, 11))
+
+	inp := &ListAvailableHotelsInput{
+		Stay: Stay{
+			CheckIn:  "2024-04-02",
+			CheckOut: "2024-04-03",
+		},
+		Occupancies: SameOccupancyRooms(5, 2, 4, 11),
+	}
+	assert.NoError(t, inp.Validate())
+
+	data, err := json.Marshal(inp)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"occupancies":[{"rooms":5,"adults":2,"children":2,"paxes":[{"type":"CH","age":4},{"type":"CH","age":11}]}]`)
+
+	inp.Occupancies = SameOccupancyRooms(10, 2)
+	assert.Equal(t, ValidationErrors{{FieldName: "Occupancy.Rooms", Min: 1, Max: 9}}, inp.Validate())
+	inp.Occupancies = SameOccupancyRooms(0, 2)
+	assert.Equal(t, ValidationErrors{{FieldName: "Occupancy.Rooms", Min: 1, Max: 9}}, inp.Validate())
+}
+
+func TestListAvailableHotelsFlattenRates(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-list-available-hotels-multi.json")
+	assert.NoError(t, err)
+
+	var resp ListAvailableHotelsResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	rates := resp.FlattenRates()
+	assert.Equal(t, 4, len(rates))
+	for i, want := range []struct {
+		hotelCode int
+		roomCode  string
+		boardCode string
+		net       string
+	}{
+		{hotelCode: 6619, roomCode: "DBL.ST", boardCode: "RO", net: "212.40"},
+		{hotelCode: 6619, roomCode: "DBL.ST", boardCode: "BB", net: "245.00"},
+		{hotelCode: 6619, roomCode: "TWN.ST", boardCode: "RO", net: "525.43"},
+		{hotelCode: 6613, roomCode: "DBL.ST", boardCode: "RO", net: "150.10"},
+	} {
+		assert.Equal(t, want.hotelCode, rates[i].HotelCode)
+		assert.Equal(t, want.roomCode, rates[i].RoomCode)
+		assert.Equal(t, want.boardCode, rates[i].Rate.BoardCode)
+		assert.Equal(t, want.net, decimal.Decimal(rates[i].Rate.Net).StringFixed(2))
+	}
+	assert.Equal(t, "Thistle London Holborn", rates[3].HotelName)
+	assert.Equal(t, "standard double", rates[3].RoomName)
+	assert.Equal(t, "EUR", rates[3].Currency)
+
+	assert.Nil(t, (&ListAvailableHotelsResponse{}).FlattenRates())
+}
+
+func TestModeValid(t *testing.T) {
+	for _, mode := range []Mode{ModeUpdate, ModeCancellation, ModeSimulation} {
+		assert.True(t, mode.Valid(), mode)
+	}
+	for _, mode := range []Mode{"", "update", "CANCEL"} {
+		assert.False(t, mode.Valid(), mode)
+	}
+	assert.Equal(t, "SIMULATION", ModeSimulation.String())
+
+	assert.NoError(t, (&CancelBookingInput{}).Validate())
+	assert.NoError(t, (&CancelBookingInput{Mode: ModeSimulation}).Validate())
+	assert.Equal(t, &ValidationError{FieldName: "Mode", Allow: []string{"CANCELLATION", "SIMULATION"}},
+		(&CancelBookingInput{Mode: ModeUpdate}).Validate())
+
+	assert.NoError(t, (&ChangeBookingInput{Mode: ModeUpdate, Booking: &Booking{}}).Validate())
+	assert.Equal(t, ValidationErrors{{FieldName: "Mode", Allow: []string{"SIMULATION", "UPDATE"}}},
+		(&ChangeBookingInput{Mode: ModeCancellation, Booking: &Booking{}}).Validate())
+	assert.Equal(t, ValidationErrors{
+		{FieldName: "Mode", Required: true, Allow: []string{"SIMULATION", "UPDATE"}},
+		{FieldName: "Booking", Required: true},
+	}, (&ChangeBookingInput{}).Validate())
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	_, err := client.CancelBooking(context.TODO(), "1-3087550", &CancelBookingInput{Mode: "CANCEL"})
+	assert.Equal(t, &ValidationError{FieldName: "Mode", Allow: []string{"CANCELLATION", "SIMULATION"}}, err)
+}
+
+func TestCheckRateTaxSummary(t *testing.T) {
+	amount := func(s string) Amount {
+		return Amount(decimal.RequireFromString(s))
+	}
+	rate := CheckRate{}
+	rate.Taxes = &struct {
+		Taxes []Tax `json:"taxes"`
+	}{
+		Taxes: []Tax{
+			{Included: true, Amount: amount("10.00"), Currency: "EUR", ClientAmount: amount("10.80"), ClientCurrency: "USD"},
+			{Included: true, Amount: amount("5.50"), Currency: "EUR", ClientAmount: amount("5.94"), ClientCurrency: "USD"},
+			{Included: false, Amount: amount("7.00"), Currency: "GBP", ClientAmount: amount("8.10"), ClientCurrency: "EUR"},
+			{Included: false, Amount: amount("3.00"), Currency: "GBP"},
+		},
+	}
+
+	included, excluded, ok := rate.TaxSummary()
+	assert.True(t, ok)
+	assert.Equal(t, "USD", included.Currency)
+	assert.Equal(t, "16.74", decimal.Decimal(included.Amount).StringFixed(2))
+	// Client amount isn't reported for all excluded taxes, so net currency is used.
+	assert.Equal(t, "GBP", excluded.Currency)
+	assert.Equal(t, "10.00", decimal.Decimal(excluded.Amount).StringFixed(2))
+
+	rate.Taxes.Taxes[3].Currency = "EUR"
+	included, excluded, ok = rate.TaxSummary()
+	assert.False(t, ok)
+	assert.Equal(t, Money{}, included)
+	assert.Equal(t, Money{}, excluded)
+
+	included, excluded, ok = CheckRate{}.TaxSummary()
+	assert.True(t, ok)
+	assert.Equal(t, Money{}, included)
+	assert.Equal(t, Money{}, excluded)
+}
+
+func TestRateClassTypeUnmarshalJSON(t *testing.T) {
+	var rate Rate
+	assert.NoError(t, json.Unmarshal([]byte(`{"rateClass":"nor","rateType":"Bookable"}`), &rate))
+	assert.Equal(t, RateClassNormal, rate.RateClass)
+	assert.Equal(t, RateTypeBookable, rate.RateType)
+	assert.True(t, rate.RateClass.Valid())
+	assert.True(t, rate.RateType.Valid())
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"rateClass":" NRF ","rateType":"recheck"}`), &rate))
+	assert.Equal(t, RateClassNonRefundable, rate.RateClass)
+	assert.Equal(t, RateTypeRecheck, rate.RateType)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"rateClass":"xyz","rateType":null}`), &rate))
+	assert.Equal(t, RateClass("XYZ"), rate.RateClass)
+	assert.False(t, rate.RateClass.Valid())
+	assert.False(t, RateType("").Valid())
+
+	data, err := json.Marshal(Rate{RateClass: RateClassOffer, RateType: RateTypeRecheck})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"rateClass":"OFE","rateType":"RECHECK"`)
+}
+
+func TestListAvailableHotelsNextPage(t *testing.T) {
+	defer gock.Off()
+
+	for page, rng := range []string{`"from":1,"to":2`, `"from":3,"to":4`} {
+		rng := rng
+		gock.New("https://api.test.hotelbeds.com").
+			Post("/hotel-api/1.0/hotels").
+			AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+				body, err := io.ReadAll(req.Body)
+				if err != nil {
+					return false, err
+				}
+				return strings.Contains(string(body), rng), nil
+			}).
+			Reply(200).
+			File(fmt.Sprintf("fixtures/200-list-available-hotels-page-%d.json", page+1))
+	}
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	inp := &ListAvailableHotelsInput{
+		Stay:        Stay{CheckIn: "2024-04-02", CheckOut: "2024-04-03"},
+		Occupancies: []Occupancy{{Rooms: 1, Adults: 1}},
+		Hotels:      FilterHotel{HotelCodes: []int{6613, 6619, 6620}},
+		From:        1,
+		To:          2,
+	}
+	var (
+		codes []int
+		pages int
+	)
+	for {
+		resp, err := client.ListAvailableHotels(context.TODO(), inp)
+		assert.NoError(t, err)
+		pages++
+		for _, hotel := range resp.Hotels.Hotels {
+			codes = append(codes, hotel.Code)
+		}
+		next, ok := resp.NextPage(inp)
+		if !ok {
+			break
+		}
+		inp = next
+	}
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, []int{6613, 6619, 6620}, codes)
+	assert.True(t, gock.IsDone())
+
+	_, ok := (&ListAvailableHotelsResponse{}).NextPage(inp)
+	assert.False(t, ok)
+}
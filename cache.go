@@ -0,0 +1,173 @@
+// Copyright (c) 2024 0x9ef. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+package hotelbeds
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// CachedContentClient is ContentClient which caches responses of reference data
+// methods (types, locations) in memory for TTL. Hotels content isn't cached.
+// Cached responses are shared between callers and must not be modified.
+type CachedContentClient struct {
+	ContentClient
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value     any
+	expiresAt time.Time
+}
+
+var _ ContentClient = (*CachedContentClient)(nil)
+
+// NewCachedContentClient returns ContentClient that serves reference data from cache within ttl.
+// The clock of api (see WithClock) is used to expire responses.
+func NewCachedContentClient(api ContentClient, ttl time.Duration) *CachedContentClient {
+	now := time.Now
+	if api, ok := api.(*API); ok {
+		now = api.now
+	}
+	return &CachedContentClient{
+		ContentClient: api,
+		ttl:           ttl,
+		now:           now,
+		entries:       make(map[string]cacheEntry),
+	}
+}
+
+// Invalidate removes all cached responses.
+func (c *CachedContentClient) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]cacheEntry)
+}
+
+func (c *CachedContentClient) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *CachedContentClient) set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cacheEntry{
+		value:     value,
+		expiresAt: c.now().Add(c.ttl),
+	}
+}
+
+// cached returns response from cache by method and input, otherwise calls f and caches successful response.
+func cached[Inp any, Resp any](ctx context.Context, c *CachedContentClient, method string, inp *Inp, f func(context.Context, *Inp) (*Resp, error)) (*Resp, error) {
+	params, err := json.Marshal(inp)
+	if err != nil {
+		return nil, err
+	}
+	key := method + string(params)
+	if value, ok := c.get(key); ok {
+		return value.(*Resp), nil
+	}
+
+	resp, err := f(ctx, inp)
+	if err != nil {
+		return nil, err
+	}
+	c.set(key, resp)
+	return resp, nil
+}
+
+func (c *CachedContentClient) ListAccommodations(ctx context.Context, inp *ListAccommodationsInput) (*ListAccommodationsResponse, error) {
+	return cached(ctx, c, "ListAccommodations", inp, c.ContentClient.ListAccommodations)
+}
+
+func (c *CachedContentClient) ListCountries(ctx context.Context, inp *ListCountriesInput) (*ListCountriesResp, error) {
+	return cached(ctx, c, "ListCountries", inp, c.ContentClient.ListCountries)
+}
+
+func (c *CachedContentClient) ListDestinations(ctx context.Context, inp *ListDestinationsInput) (*ListDestinationsResponse, error) {
+	return cached(ctx, c, "ListDestinations", inp, c.ContentClient.ListDestinations)
+}
+
+func (c *CachedContentClient) ListBoards(ctx context.Context, inp *ListBoardsInput) (*ListBoardsResponse, error) {
+	return cached(ctx, c, "ListBoards", inp, c.ContentClient.ListBoards)
+}
+
+func (c *CachedContentClient) ListBoardGroups(ctx context.Context, inp *ListBoardGroupsInput) (*ListBoardGroupsResponse, error) {
+	return cached(ctx, c, "ListBoardGroups", inp, c.ContentClient.ListBoardGroups)
+}
+
+func (c *CachedContentClient) ListCategories(ctx context.Context, inp *ListCategoriesInput) (*ListCategoriesResponse, error) {
+	return cached(ctx, c, "ListCategories", inp, c.ContentClient.ListCategories)
+}
+
+func (c *CachedContentClient) ListChains(ctx context.Context, inp *ListChainsInput) (*ListChainsResponse, error) {
+	return cached(ctx, c, "ListChains", inp, c.ContentClient.ListChains)
+}
+
+func (c *CachedContentClient) ListClassifications(ctx context.Context, inp *ListClassificationsInput) (*ListClassificationsResponse, error) {
+	return cached(ctx, c, "ListClassifications", inp, c.ContentClient.ListClassifications)
+}
+
+func (c *CachedContentClient) ListCurrencies(ctx context.Context, inp *ListCurrenciesInput) (*ListCurrenciesResponse, error) {
+	return cached(ctx, c, "ListCurrencies", inp, c.ContentClient.ListCurrencies)
+}
+
+func (c *CachedContentClient) ListFacilities(ctx context.Context, inp *ListFacilitiesInput) (*ListFacilitiesResponse, error) {
+	return cached(ctx, c, "ListFacilities", inp, c.ContentClient.ListFacilities)
+}
+
+func (c *CachedContentClient) ListFacilityGroups(ctx context.Context, inp *ListFacilityGroupsInput) (*ListFacilityGroupsResponse, error) {
+	return cached(ctx, c, "ListFacilityGroups", inp, c.ContentClient.ListFacilityGroups)
+}
+
+func (c *CachedContentClient) ListFacilityTypologies(ctx context.Context, inp *ListFacilityTypologiesInput) (*ListFacilityTypologiesResponse, error) {
+	return cached(ctx, c, "ListFacilityTypologies", inp, c.ContentClient.ListFacilityTypologies)
+}
+
+func (c *CachedContentClient) ListImageTypes(ctx context.Context, inp *ListImageTypesInput) (*ListImageTypesResponse, error) {
+	return cached(ctx, c, "ListImageTypes", inp, c.ContentClient.ListImageTypes)
+}
+
+func (c *CachedContentClient) ListIssues(ctx context.Context, inp *ListIssuesInput) (*ListIssuesResponse, error) {
+	return cached(ctx, c, "ListIssues", inp, c.ContentClient.ListIssues)
+}
+
+func (c *CachedContentClient) ListLanguages(ctx context.Context, inp *ListLanguagesInput) (*ListLanguagesResponse, error) {
+	return cached(ctx, c, "ListLanguages", inp, c.ContentClient.ListLanguages)
+}
+
+func (c *CachedContentClient) ListPromotions(ctx context.Context, inp *ListPromotionsInput) (*ListPromotionsResponse, error) {
+	return cached(ctx, c, "ListPromotions", inp, c.ContentClient.ListPromotions)
+}
+
+func (c *CachedContentClient) ListRooms(ctx context.Context, inp *ListRoomsInput) (*ListRoomsResponse, error) {
+	return cached(ctx, c, "ListRooms", inp, c.ContentClient.ListRooms)
+}
+
+func (c *CachedContentClient) ListRateComments(ctx context.Context, inp *ListRateCommentsInput) (*ListRateCommentsResponse, error) {
+	return cached(ctx, c, "ListRateComments", inp, c.ContentClient.ListRateComments)
+}
+
+func (c *CachedContentClient) ListSegments(ctx context.Context, inp *ListSegmentsInput) (*ListSegmentsResponse, error) {
+	return cached(ctx, c, "ListSegments", inp, c.ContentClient.ListSegments)
+}
+
+func (c *CachedContentClient) ListTerminals(ctx context.Context, inp *ListTerminalsInput) (*ListTerminalsResponse, error) {
+	return cached(ctx, c, "ListTerminals", inp, c.ContentClient.ListTerminals)
+}
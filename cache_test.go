@@ -0,0 +1,53 @@
+// Copyright (c) 2024 0x9ef. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+package hotelbeds
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestCachedContentClient(t *testing.T) {
+	defer gock.Off()
+
+	mockBoards := func() {
+		gock.New("https://api.test.hotelbeds.com").
+			Get("/hotel-content-api/1.0/types/boards").
+			Reply(200).
+			File("fixtures/200-list-types-boards.json")
+	}
+
+	now := time.Now()
+	api := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"),
+		WithClock(func() time.Time { return now }))
+	client := NewCachedContentClient(api, time.Hour)
+
+	// Underlying API is called once within the TTL.
+	mockBoards()
+	for i := 0; i < 3; i++ {
+		resp, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(resp.Boards))
+	}
+	assert.True(t, gock.IsDone())
+
+	// And again after expiry.
+	now = now.Add(time.Hour)
+	mockBoards()
+	_, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+
+	// And again after invalidation.
+	client.Invalidate()
+	mockBoards()
+	_, err = client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+}
@@ -4,8 +4,9 @@
 package hotelbeds
 
 import (
+	"bytes"
 	"context"
-	"errors"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -16,6 +17,8 @@ import (
 	"github.com/0x9ef/clientx"
 )
 
+// ContentClient is the client of Content API endpoints. Helpers built on top of the endpoints
+// (e.g. GetHotelImages) are methods of API, so implementations of the interface needn't provide them.
 type ContentClient interface {
 	ListHotels(ctx context.Context, inp *ListHotelsInput) (*ListHotelsResponse, error)
 	GetHotelDetails(ctx context.Context, codes []int, inp *GetHotelDetailsInput) (*GetHotelDetailsResponse, error)
@@ -61,10 +64,10 @@ type (
 		PostalCode           string               `json:"postalCode"`
 		City                 Content              `json:"city"`
 		Email                string               `json:"email"`
-		License              string               `json:"license,omitempty"`
+		License              LooseString          `json:"license,omitempty"`
 		URL                  string               `json:"web"`
 		LastUpdate           Datetime             `json:"lastUpdate"`
-		S2C                  string               `json:"S2C"`
+		S2C                  LooseString          `json:"S2C,omitempty"`
 		Ranking              int                  `json:"ranking"`
 		Phones               []Phone              `json:"phones"`
 		Rooms                []HotelRoom          `json:"rooms"`
@@ -74,6 +77,8 @@ type (
 		Terminals            []HotelTerminal      `json:"terminals"`
 		InterestPoints       []HotelInterestPoint `json:"interestPoints"`
 		Images               []HotelImage         `json:"images,omitempty"`
+		// GiataCode is the GIATA property identifier, returned for mapped hotels.
+		GiataCode LooseString `json:"giataCode,omitempty"`
 	}
 
 	HotelAccomodation struct {
@@ -93,6 +98,9 @@ type (
 		Type               string              `json:"roomType"`
 		CharacteristicCode string              `json:"characteristicCode"`
 		Facilities         []HotelRoomFacility `json:"roomFacilities"`
+		// PMSRoomCode is the room code of the property management system, returned
+		// for mapped rooms (see ListHotelsInput.OnlyPMSRoomCode).
+		PMSRoomCode string `json:"PMSRoomCode,omitempty"`
 	}
 
 	HotelRoomFacility struct {
@@ -162,62 +170,68 @@ type (
 
 	ListHotelsInput struct {
 		// Filter for a specific hotel or list of hotels.
-		Codes []int `url:"hotelCode"`
+		Codes []int
 		// Filter to limit the results for an specific country.
-		CountryCode string `url:"countryCode"`
+		CountryCode string
 		// Filter to limit the results for an specific destination.
-		DestinationCode string `url:"destinationCode"`
+		DestinationCode string
 		// Use "webOnly" to include in the response hotels sellable only to websites.
 		// Use "notOnSale" to include in the response hotels without rates on sale.
 		// By default non of them is included in the response.
-		IncludeHotels IncludeHotels `url:"includeHotels"`
+		IncludeHotels IncludeHotels
 		// The list of fields to be received in the response. To retrieve all the fields use ‘all’.
 		// If nothing is specified, all fields are returned. See the complete list of available fields in the response.
-		Fields []string `url:"fields"`
+		Fields []string
 		// The language code for the language in which you want the descriptions to be returned.
 		// If language is not specified, English will be used as default language.
-		Language string `url:"language"`
+		Language string
 		// The number of the initial record to receive. If nothing is specified, 1 is the default value.
-		From int `url:"from"`
+		From int
 		// The number of the final record to receive. If nothing is indicated, 100 is the default value.
-		To int `url:"to"`
+		To int
 		// Defines if you want to receive the descriptions in English if the description
 		// is not available in the language requested.
-		UseSecondaryLanguage *bool `url:"useSecondaryLanguage"`
+		UseSecondaryLanguage *bool
 		// Specifying this parameter limits the results to those modified or added
 		// after the date specified. The required format is YYYY-MM-DD.
-		LastUpdateTime Datetime `url:"lastUpdateTime"`
+		LastUpdateTime Datetime
 		// Sending this parameter as true in the /hotels operations will only return
 		// the hotels which possess at least one PMSRoomCode (useful when mapping against the original property codes).
-		OnlyPMSRoomCode *bool `url:"PMSRoomCode"`
+		OnlyPMSRoomCode *bool
 	}
 
 	ListHotelsResponse struct {
-		From   int        `json:"from"`
-		To     int        `json:"to"`
-		Total  int        `json:"total"`
-		Audit  *AuditData `json:"auditData"`
-		Hotels []Hotel    `json:"hotels"`
+		From   int               `json:"from"`
+		To     int               `json:"to"`
+		Total  int               `json:"total"`
+		Audit  *AuditData        `json:"auditData"`
+		Hotels LooseSlice[Hotel] `json:"hotels"`
 	}
 
 	GetHotelDetailsInput struct {
-		Language             string `url:"language"`
-		UseSecondaryLanguage *bool  `url:"useSecondaryLanguage"`
+		Language             string
+		UseSecondaryLanguage *bool
+		// The list of fields to be received in the response.
+		// If nothing is specified, all fields are returned.
+		Fields []string
+		// Requests all fields except images (ignored if Fields specified),
+		// use GetHotelImages to fetch images separately.
+		ExcludeImages bool
 	}
 
 	GetHotelDetailsResponse struct {
-		Audit  *AuditData `json:"auditData"`
-		Hotels []Hotel    `json:"hotels"`
+		Audit  *AuditData        `json:"auditData"`
+		Hotels LooseSlice[Hotel] `json:"hotels"`
 	}
 
 	ListInput struct {
-		Fields               []string  `url:"fields,omitempty"`
-		Codes                []string  `url:"codes,omitempty"`
-		Language             string    `url:"language,omitempty"`
-		From                 int       `url:"from"`
-		To                   int       `url:"to"`
-		UseSecondaryLanguage bool      `url:"useSecondaryLanguage"`
-		LastUpdateTime       *Datetime `url:"lastUpdateTime,omitempty"`
+		Fields               []string
+		Codes                []string
+		Language             string
+		From                 int
+		To                   int
+		UseSecondaryLanguage bool
+		LastUpdateTime       *Datetime
 	}
 
 	ListCountriesInput struct {
@@ -299,7 +313,6 @@ type (
 	}
 
 	ListBoardGroupsInput struct {
-		Codes []string `json:"codes"`
 		ListInput
 	}
 
@@ -494,7 +507,6 @@ type (
 	}
 
 	ListRoomsInput struct {
-		Codes []string `json:"codes"`
 		ListInput
 	}
 
@@ -546,6 +558,25 @@ type (
 		RateComments []RateComment `json:"rateComments"`
 	}
 
+	// getRateCommentsInput is the query of rate comment details, code is
+	// the rate comments ID in the format "incoming|hotel|code".
+	getRateCommentsInput struct {
+		Code string
+		Date Datetime
+	}
+
+	GetRateCommentsResponse struct {
+		Audit *AuditData `json:"auditData"`
+		// Date for which the comments are valid.
+		Date Datetime `json:"date"`
+		// Code of the incoming office of the hotel.
+		Incoming    int    `json:"incoming"`
+		HotelCode   int    `json:"hotel"`
+		Code        string `json:"code"`
+		RateCodes   []int  `json:"rateCodes"`
+		Description string `json:"description"`
+	}
+
 	Terminal struct {
 		Code        string  `json:"code"`
 		Type        string  `json:"type"`
@@ -580,11 +611,165 @@ type Address struct {
 	Number  string `json:"number,omitempty"`
 }
 
+// Formatted returns address as a single line. Street and number are preferred,
+// content is appended only if it's not already a part of them and vice versa.
+func (a Address) Formatted() string {
+	street, number := strings.TrimSpace(a.Street), strings.TrimSpace(a.Number)
+	content := strings.TrimSpace(a.Content)
+
+	line := street
+	if number != "" {
+		line = joinNonEmpty(", ", street, number)
+	}
+	switch {
+	case content == "":
+		return line
+	case line == "":
+		return content
+	case containsFold(content, street) && (number == "" || containsFold(content, number)):
+		return content
+	case containsFold(line, content):
+		return line
+	}
+	return line + ", " + content
+}
+
+// MatchWildcard returns wildcard of the room type (e.g. "DBL.ST" as returned by availability),
+// which contains hotel specific description of the room.
+func (h *Hotel) MatchWildcard(roomType string) (HotelWildCard, bool) {
+	for _, wildcard := range h.Wildcards {
+		if wildcard.RoomType == roomType || wildcard.RoomCode+"."+wildcard.CharacteristicCode == roomType {
+			return wildcard, true
+		}
+	}
+	return HotelWildCard{}, false
+}
+
+// ResolveWildcards returns room of every wildcard. Hotel room with the same code is used if exists,
+// otherwise room is built from the wildcard room code and characteristic code.
+func (h *Hotel) ResolveWildcards() []HotelRoom {
+	rooms := make([]HotelRoom, 0, len(h.Wildcards))
+	for _, wildcard := range h.Wildcards {
+		room := HotelRoom{
+			Code:               wildcard.RoomType,
+			Type:               wildcard.RoomCode,
+			CharacteristicCode: wildcard.CharacteristicCode,
+		}
+		for _, hotelRoom := range h.Rooms {
+			if hotelRoom.Code == wildcard.RoomType {
+				room = hotelRoom
+				break
+			}
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// PMSRoomCodes returns PMS room codes of the hotel rooms by room code.
+// Rooms without PMS room code are omitted.
+func (h *Hotel) PMSRoomCodes() map[string]string {
+	codes := make(map[string]string)
+	for _, room := range h.Rooms {
+		if room.PMSRoomCode != "" {
+			codes[room.Code] = room.PMSRoomCode
+		}
+	}
+	return codes
+}
+
+// Accommodates reports whether the party of adults and children fits the room occupancy limits.
+// Rooms without occupancy data (zero MaxPax, e.g. resolved wildcards) accommodate any party,
+// otherwise limits are applied as is, so a room with zero MaxChildren is adults-only.
+func (r HotelRoom) Accommodates(adults, children int) bool {
+	if adults < 0 || children < 0 {
+		return false
+	}
+	if r.MaxPax == 0 {
+		return true
+	}
+	within := func(n, min, max int) bool {
+		return n >= min && n <= max
+	}
+	return within(adults, r.MinAdults, r.MaxAdults) &&
+		within(children, r.MinChildren, r.MaxChildren) &&
+		within(adults+children, r.MinPax, r.MaxPax)
+}
+
+// HasLicense reports whether the hotel has a tourism license (registration) number,
+// which is required to be displayed in some regions.
+func (h *Hotel) HasLicense() bool {
+	return strings.TrimSpace(h.License.String()) != ""
+}
+
+// FullAddress returns formatted address followed by postal code and city.
+// City is omitted if the address already contains it.
+func (h *Hotel) FullAddress() string {
+	address := h.Address.Formatted()
+	city := strings.TrimSpace(h.City.Content)
+	if containsFold(address, city) {
+		city = ""
+	}
+	return joinNonEmpty(", ", address, strings.TrimSpace(h.PostalCode), city)
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func joinNonEmpty(sep string, elems ...string) string {
+	nonEmpty := make([]string, 0, len(elems))
+	for _, elem := range elems {
+		if elem != "" {
+			nonEmpty = append(nonEmpty, elem)
+		}
+	}
+	return strings.Join(nonEmpty, sep)
+}
+
 type Coordinates struct {
 	Long float64 `json:"longitude"`
 	Lat  float64 `json:"latitude"`
 }
 
+// UnmarshalJSON decodes coordinates from an object with numeric or string
+// latitude and longitude, or from a "latitude,longitude" string.
+func (c *Coordinates) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	if data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return fmt.Errorf("failed to parse Coordinates: %w", err)
+		}
+		lat, long, ok := strings.Cut(str, ",")
+		if !ok {
+			return fmt.Errorf("failed to parse Coordinates: %q isn't latitude,longitude pair", str)
+		}
+		var err error
+		if c.Lat, err = strconv.ParseFloat(strings.TrimSpace(lat), 64); err != nil {
+			return fmt.Errorf("failed to parse Coordinates: %w", err)
+		}
+		if c.Long, err = strconv.ParseFloat(strings.TrimSpace(long), 64); err != nil {
+			return fmt.Errorf("failed to parse Coordinates: %w", err)
+		}
+		return nil
+	}
+
+	var coords struct {
+		Long Coordinate `json:"longitude"`
+		Lat  Coordinate `json:"latitude"`
+	}
+	if err := json.Unmarshal(data, &coords); err != nil {
+		return fmt.Errorf("failed to parse Coordinates: %w", err)
+	}
+	c.Long, c.Lat = float64(coords.Long), float64(coords.Lat)
+	return nil
+}
+
 type Phone struct {
 	Number string    `json:"phoneNumber"`
 	Type   PhoneType `json:"phoneType"`
@@ -599,6 +784,27 @@ const (
 	PhoneTypeManagement PhoneType = "PHONEMANAGEMENT"
 )
 
+// Valid reports whether phone type is one of the known types.
+func (t PhoneType) Valid() bool {
+	switch t {
+	case PhoneTypeHotel, PhoneTypeBooking, PhoneTypeFax, PhoneTypeManagement:
+		return true
+	}
+	return false
+}
+
+// PhonesE164 returns hotel phones converted into E164 format by phone type.
+// Invalid numbers are skipped, for duplicated phone types the last number wins.
+func (h *Hotel) PhonesE164() map[PhoneType]string {
+	phones := make(map[PhoneType]string, len(h.Phones))
+	for _, phone := range h.Phones {
+		if number := ParseE164(phone.Number); number != "" {
+			phones[phone.Type] = number
+		}
+	}
+	return phones
+}
+
 type IncludeHotels string
 
 const (
@@ -624,20 +830,68 @@ func (sc SimpleCode) Int() int {
 	return int(sc)
 }
 
+// Stars resolves hotel CategoryCode to the star rating (SimpleCode) using categories
+// reference obtained from ListCategories. Returns false if category is unknown.
+func (h *Hotel) Stars(cats []Category) (int, bool) {
+	for _, cat := range cats {
+		if cat.Code == h.CategoryCode {
+			return cat.SimpleCode.Int(), cat.SimpleCode != 0
+		}
+	}
+	return 0, false
+}
+
+// ByIsoCode returns country by its ISO 3166-1 alpha-2 code (e.g. "GB"), which may
+// differ from the Hotelbeds code of the country (e.g. "UK"). Lookup is case-insensitive.
+func (resp *ListCountriesResp) ByIsoCode(iso string) (Country, bool) {
+	for _, country := range resp.Countries {
+		if strings.EqualFold(country.IsoCode, iso) {
+			return country, true
+		}
+	}
+	return Country{}, false
+}
+
+// ByCode returns country by its Hotelbeds code, it's the reverse lookup of ByIsoCode.
+func (resp *ListCountriesResp) ByCode(code string) (Country, bool) {
+	for _, country := range resp.Countries {
+		if strings.EqualFold(country.Code, code) {
+			return country, true
+		}
+	}
+	return Country{}, false
+}
+
 const minFromParam = 1
-const maxToParam = 1000
+
+// maxPageSize is the maximal number of records (To-From+1) returned at once.
+const maxPageSize = 1000
 
 func (inp *ListHotelsInput) Validate() error {
-	if inp.From != 0 && inp.From < 1 {
-		return errors.New("From param < 1")
-	}
-	if inp.To != 0 && inp.To > maxToParam {
-		return errors.New("To param > 1000")
+	var errs ValidationErrors
+	if inp.From != 0 && inp.From < minFromParam {
+		errs.add(&ValidationError{
+			FieldName: "From",
+			Min:       minFromParam,
+		})
+	}
+	from := inp.From
+	if from == 0 {
+		from = minFromParam
+	}
+	if inp.To != 0 && inp.To-from+1 > maxPageSize {
+		errs.add(&ValidationError{
+			FieldName: "To",
+			Max:       from + maxPageSize - 1,
+		})
 	}
 	if inp.IncludeHotels != "" && inp.IncludeHotels != IncludeHotelsWebOnly && inp.IncludeHotels != IncludeHotelsNotOnSale {
-		return errors.New("IncludeHotels invalid, only webOnly, notOnSale supported")
+		errs.add(&ValidationError{
+			FieldName: "IncludeHotels",
+			Allow:     []string{IncludeHotelsWebOnly.String(), IncludeHotelsNotOnSale.String()},
+		})
 	}
-	return nil
+	return errs.err()
 }
 
 func (inp ListHotelsInput) Encode(v url.Values) error {
@@ -681,6 +935,37 @@ func (inp ListHotelsInput) Encode(v url.Values) error {
 	return nil
 }
 
+func (inp ListInput) Encode(v url.Values) error {
+	if len(inp.Fields) != 0 {
+		v.Set("fields", strings.Join(inp.Fields, ","))
+	}
+	if len(inp.Codes) != 0 {
+		v.Set("codes", strings.Join(inp.Codes, ","))
+	}
+	if inp.Language != "" {
+		v.Set("language", inp.Language)
+	}
+	if inp.From != 0 {
+		v.Set("from", strconv.Itoa(inp.From))
+	}
+	if inp.To != 0 {
+		v.Set("to", strconv.Itoa(inp.To))
+	}
+	if inp.UseSecondaryLanguage {
+		v.Set("useSecondaryLanguage", "true")
+	}
+	if inp.LastUpdateTime != nil && !inp.LastUpdateTime.IsZero() {
+		v.Set("lastUpdateTime", inp.LastUpdateTime.String())
+	}
+	return nil
+}
+
+func (inp getRateCommentsInput) Encode(v url.Values) error {
+	v.Set("code", inp.Code)
+	v.Set("date", inp.Date.String())
+	return nil
+}
+
 func (inp GetHotelDetailsInput) Encode(v url.Values) error {
 	if inp.Language != "" {
 		v.Set("language", inp.Language)
@@ -688,9 +973,49 @@ func (inp GetHotelDetailsInput) Encode(v url.Values) error {
 	if inp.UseSecondaryLanguage != nil {
 		v.Set("useSecondaryLanguage", strconv.FormatBool(*inp.UseSecondaryLanguage))
 	}
+	if len(inp.Fields) != 0 {
+		v.Set("fields", strings.Join(inp.Fields, ","))
+	} else if inp.ExcludeImages {
+		v.Set("fields", strings.Join(hotelFieldsWithoutImages, ","))
+	}
 	return nil
 }
 
+// hotelFieldsWithoutImages is the list of all hotel details fields except images.
+var hotelFieldsWithoutImages = []string{
+	"code", "name", "description", "country", "state", "destination", "zone", "coordinates",
+	"category", "categoryGroup", "chain", "accommodationType", "boards", "segments", "address",
+	"postalCode", "city", "email", "license", "phones", "rooms", "facilities", "terminals",
+	"issues", "interestPoints", "wildcards", "web", "lastUpdate", "S2C", "ranking",
+}
+
+// listHotelsPageSize is the number of hotels fetched at once by HotelsByChain.
+var listHotelsPageSize = maxPageSize
+
+// HotelsByChain returns all hotels of the chain (see ListChains). Hotels endpoint has no chain
+// filter, so it walks the entire hotel catalogue page by page (hundreds of requests for a full
+// catalogue) and filters hotels by ChainCode on the client side. Cache the result if possible.
+func (api *API) HotelsByChain(ctx context.Context, chainCode string) ([]Hotel, error) {
+	var hotels []Hotel
+	for from := 1; ; from += listHotelsPageSize {
+		resp, err := api.ListHotels(ctx, &ListHotelsInput{
+			From: from,
+			To:   from + listHotelsPageSize - 1,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list hotels from %d: %w", from, err)
+		}
+		for _, hotel := range resp.Hotels {
+			if strings.EqualFold(hotel.ChainCode, chainCode) {
+				hotels = append(hotels, hotel)
+			}
+		}
+		if len(resp.Hotels) < listHotelsPageSize || from+listHotelsPageSize > resp.Total {
+			return hotels, nil
+		}
+	}
+}
+
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/hotelsUsingGET
 func (api *API) ListHotels(ctx context.Context, inp *ListHotelsInput) (*ListHotelsResponse, error) {
 	if err := inp.Validate(); err != nil {
@@ -698,241 +1023,285 @@ func (api *API) ListHotels(ctx context.Context, inp *ListHotelsInput) (*ListHote
 	}
 
 	return clientx.NewRequestBuilder[ListHotelsInput, ListHotelsResponse](api.API).
-		Get("/hotel-content-api/1.0/hotels", clientx.WithRequestHeaders(api.buildHeaders())).
+		Get(api.path("/hotel-content-api/1.0/hotels"), api.withRequestHeaders()).
 		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/hotelWithIdDetailsUsingGET
 func (api *API) GetHotelDetails(ctx context.Context, codes []int, inp *GetHotelDetailsInput) (*GetHotelDetailsResponse, error) {
 	return clientx.NewRequestBuilder[GetHotelDetailsInput, GetHotelDetailsResponse](api.API).
-		Get(fmt.Sprintf("/hotel-content-api/1.0/hotels/%s/details", joinInts[int](codes)), clientx.WithRequestHeaders(api.buildHeaders())).
+		Get(api.path(fmt.Sprintf("/hotel-content-api/1.0/hotels/%s/details", joinInts[int](codes))), api.withRequestHeaders()).
 		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
+}
+
+// GetHotelImages fetches only images of the hotel.
+func (api *API) GetHotelImages(ctx context.Context, code int) ([]HotelImage, error) {
+	resp, err := api.GetHotelDetails(ctx, []int{code}, &GetHotelDetailsInput{
+		Fields: []string{"images"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Hotels) == 0 {
+		return nil, nil
+	}
+	return resp.Hotels[0].Images, nil
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/accommodatinsUsingGET
 func (api *API) ListAccommodations(ctx context.Context, inp *ListAccommodationsInput) (*ListAccommodationsResponse, error) {
 	return clientx.NewRequestBuilder[ListAccommodationsInput, ListAccommodationsResponse](api.API).
-		Get("/hotel-content-api/1.0/types/accommodations", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/accommodations"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/countriesUsingGET
 func (api *API) ListCountries(ctx context.Context, inp *ListCountriesInput) (*ListCountriesResp, error) {
 	return clientx.NewRequestBuilder[ListCountriesInput, ListCountriesResp](api.API).
-		Get("/hotel-content-api/1.0/locations/countries", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/locations/countries"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/destinationsUsingGET
 func (api *API) ListDestinations(ctx context.Context, inp *ListDestinationsInput) (*ListDestinationsResponse, error) {
 	return clientx.NewRequestBuilder[ListDestinationsInput, ListDestinationsResponse](api.API).
-		Get("/hotel-content-api/1.0/locations/destinations", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/locations/destinations"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/boardsUsingGET
 func (api *API) ListBoards(ctx context.Context, inp *ListBoardsInput) (*ListBoardsResponse, error) {
 	return clientx.NewRequestBuilder[ListBoardsInput, ListBoardsResponse](api.API).
-		Get("/hotel-content-api/1.0/types/boards", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/boards"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/boardGroupsUsingGET
 func (api *API) ListBoardGroups(ctx context.Context, inp *ListBoardGroupsInput) (*ListBoardGroupsResponse, error) {
 	return clientx.NewRequestBuilder[ListBoardGroupsInput, ListBoardGroupsResponse](api.API).
-		Get("/hotel-content-api/1.0/types/boardgroups", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/boardgroups"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/categoriesUsingGET
 func (api *API) ListCategories(ctx context.Context, inp *ListCategoriesInput) (*ListCategoriesResponse, error) {
 	return clientx.NewRequestBuilder[ListCategoriesInput, ListCategoriesResponse](api.API).
-		Get("/hotel-content-api/1.0/types/categories", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/categories"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/chainsUsingGET
 func (api *API) ListChains(ctx context.Context, inp *ListChainsInput) (*ListChainsResponse, error) {
 	return clientx.NewRequestBuilder[ListChainsInput, ListChainsResponse](api.API).
-		Get("/hotel-content-api/1.0/types/chains", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/chains"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/classificationsUsingGET
 func (api *API) ListClassifications(ctx context.Context, inp *ListClassificationsInput) (*ListClassificationsResponse, error) {
 	return clientx.NewRequestBuilder[ListClassificationsInput, ListClassificationsResponse](api.API).
-		Get("/hotel-content-api/1.0/types/classifications", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/classifications"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/currenciesUsingGET
 func (api *API) ListCurrencies(ctx context.Context, inp *ListCurrenciesInput) (*ListCurrenciesResponse, error) {
 	return clientx.NewRequestBuilder[ListCurrenciesInput, ListCurrenciesResponse](api.API).
-		Get("/hotel-content-api/1.0/types/currencies", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/currencies"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/facilitiesUsingGET
 func (api *API) ListFacilities(ctx context.Context, inp *ListFacilitiesInput) (*ListFacilitiesResponse, error) {
 	return clientx.NewRequestBuilder[ListFacilitiesInput, ListFacilitiesResponse](api.API).
-		Get("/hotel-content-api/1.0/types/facilities", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/facilities"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/facilitygroupsUsingGET
 func (api *API) ListFacilityGroups(ctx context.Context, inp *ListFacilityGroupsInput) (*ListFacilityGroupsResponse, error) {
 	return clientx.NewRequestBuilder[ListFacilityGroupsInput, ListFacilityGroupsResponse](api.API).
-		Get("/hotel-content-api/1.0/types/facilitygroups", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/facilitygroups"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/facilitytypologiesUsingGET
 func (api *API) ListFacilityTypologies(ctx context.Context, inp *ListFacilityTypologiesInput) (*ListFacilityTypologiesResponse, error) {
 	return clientx.NewRequestBuilder[ListFacilityTypologiesInput, ListFacilityTypologiesResponse](api.API).
-		Get("/hotel-content-api/1.0/types/facilitytypologies", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/facilitytypologies"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/imagetypesUsingGET
 func (api *API) ListImageTypes(ctx context.Context, inp *ListImageTypesInput) (*ListImageTypesResponse, error) {
 	return clientx.NewRequestBuilder[ListImageTypesInput, ListImageTypesResponse](api.API).
-		Get("/hotel-content-api/1.0/types/imagetypes", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/imagetypes"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/issuesUsingGET
 func (api *API) ListIssues(ctx context.Context, inp *ListIssuesInput) (*ListIssuesResponse, error) {
 	return clientx.NewRequestBuilder[ListIssuesInput, ListIssuesResponse](api.API).
-		Get("/hotel-content-api/1.0/types/issues", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/issues"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/languagesUsingGET
 func (api *API) ListLanguages(ctx context.Context, inp *ListLanguagesInput) (*ListLanguagesResponse, error) {
 	return clientx.NewRequestBuilder[ListLanguagesInput, ListLanguagesResponse](api.API).
-		Get("/hotel-content-api/1.0/types/languages", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/languages"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/promotionsUsingGET
 func (api *API) ListPromotions(ctx context.Context, inp *ListPromotionsInput) (*ListPromotionsResponse, error) {
 	return clientx.NewRequestBuilder[ListPromotionsInput, ListPromotionsResponse](api.API).
-		Get("/hotel-content-api/1.0/types/promotions", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/promotions"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/roomsUsingGET
 func (api *API) ListRooms(ctx context.Context, inp *ListRoomsInput) (*ListRoomsResponse, error) {
 	return clientx.NewRequestBuilder[ListRoomsInput, ListRoomsResponse](api.API).
-		Get("/hotel-content-api/1.0/types/rooms", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/rooms"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/rateCommentsUsingGET
 func (api *API) ListRateComments(ctx context.Context, inp *ListRateCommentsInput) (*ListRateCommentsResponse, error) {
 	return clientx.NewRequestBuilder[ListRateCommentsInput, ListRateCommentsResponse](api.API).
-		Get("/hotel-content-api/1.0/types/ratecomments", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/ratecomments"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
+		WithErrorDecode(func(resp *http.Response) (bool, error) {
+			return resp.StatusCode > 399, decodeError(resp)
+		}).
+		DoWithDecode(ctx, api.decoder())
+}
+
+// GetRateComments fetches rate comments by the rate comments ID (Rate.RateCommentdsID),
+// which are valid on the check-in date.
+// Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/rateCommentDetailsUsingGET
+func (api *API) GetRateComments(ctx context.Context, commentsID string, checkIn Datetime) (*GetRateCommentsResponse, error) {
+	if commentsID == "" {
+		return nil, &ValidationError{
+			FieldName: "CommentsID",
+			Required:  true,
+		}
+	}
+	if checkIn.IsZero() {
+		return nil, &ValidationError{
+			FieldName: "CheckIn",
+			Required:  true,
+		}
+	}
+
+	inp := &getRateCommentsInput{
+		Code: commentsID,
+		Date: checkIn,
+	}
+	return clientx.NewRequestBuilder[getRateCommentsInput, GetRateCommentsResponse](api.API).
+		Get(api.path("/hotel-content-api/1.0/types/ratecommentdetails"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/segmentsUsingGET
 func (api *API) ListSegments(ctx context.Context, inp *ListSegmentsInput) (*ListSegmentsResponse, error) {
 	return clientx.NewRequestBuilder[ListSegmentsInput, ListSegmentsResponse](api.API).
-		Get("/hotel-content-api/1.0/types/segments", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/segments"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
 
 // Ref - https://developer.hotelbeds.com/documentation/hotels/content-api/api-reference/#operation/terminalsUsingGET
 func (api *API) ListTerminals(ctx context.Context, inp *ListTerminalsInput) (*ListTerminalsResponse, error) {
 	return clientx.NewRequestBuilder[ListTerminalsInput, ListTerminalsResponse](api.API).
-		Get("/hotel-content-api/1.0/types/terminals", clientx.WithRequestHeaders(api.buildHeaders())).
-		WithQueryParams("url", *inp).
+		Get(api.path("/hotel-content-api/1.0/types/terminals"), api.withRequestHeaders()).
+		WithEncodableQueryParams(inp).
 		WithErrorDecode(func(resp *http.Response) (bool, error) {
 			return resp.StatusCode > 399, decodeError(resp)
 		}).
-		DoWithDecode(ctx)
+		DoWithDecode(ctx, api.decoder())
 }
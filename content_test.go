@@ -5,7 +5,10 @@ package hotelbeds
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -34,6 +37,30 @@ func TestListHotels(t *testing.T) {
 	assert.Equal(t, resp.Hotels[1].Code, 6619)
 }
 
+func TestListHotelsOnlyPMSRoomCode(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/hotels").
+		MatchParam("PMSRoomCode", "true").
+		Reply(200).
+		File("fixtures/200-list-hotels-pms-room-codes.json")
+
+	onlyPMSRoomCode := true
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	resp, err := client.ListHotels(context.TODO(), &ListHotelsInput{
+		Codes:           []int{6619},
+		OnlyPMSRoomCode: &onlyPMSRoomCode,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(resp.Hotels))
+
+	hotel := resp.Hotels[0]
+	assert.Equal(t, LooseString("12345"), hotel.GiataCode)
+	assert.Equal(t, "DBLSTD", hotel.Rooms[0].PMSRoomCode)
+	assert.Equal(t, map[string]string{"DBL.ST": "DBLSTD", "TWN.ST": "TWNSTD"}, hotel.PMSRoomCodes())
+}
+
 func TestGetHotelDetails(t *testing.T) {
 	defer gock.Off()
 
@@ -53,6 +80,66 @@ func TestGetHotelDetails(t *testing.T) {
 	assert.Equal(t, resp.Hotels[1].Code, 6619)
 }
 
+func TestGetHotelDetailsExcludeImages(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/hotels/6613,6619/details").
+		MatchParam("fields", "^code,name,").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			return !strings.Contains(req.URL.Query().Get("fields"), "images"), nil
+		}).
+		Reply(200).
+		File("fixtures/200-get-hotel-details-no-images.json")
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	resp, err := client.GetHotelDetails(context.TODO(), []int{6613, 6619}, &GetHotelDetailsInput{
+		ExcludeImages: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(resp.Hotels))
+	assert.Equal(t, "The Savoy", resp.Hotels[0].Name.Content)
+	assert.Empty(t, resp.Hotels[0].Images)
+	assert.Empty(t, resp.Hotels[1].Images)
+}
+
+func TestHotelHasLicense(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-get-hotel-details-license.json")
+	assert.NoError(t, err)
+
+	var resp GetHotelDetailsResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+	assert.Equal(t, 4, len(resp.Hotels))
+
+	assert.True(t, resp.Hotels[0].HasLicense())
+	assert.Equal(t, LooseString("HB-004387"), resp.Hotels[0].License)
+	assert.True(t, resp.Hotels[1].HasLicense())
+	assert.Equal(t, "117684", resp.Hotels[1].License.String())
+	assert.Equal(t, LooseString("3"), resp.Hotels[1].S2C)
+	assert.False(t, resp.Hotels[2].HasLicense())
+	assert.False(t, resp.Hotels[3].HasLicense())
+	assert.Empty(t, resp.Hotels[3].S2C)
+
+	var s LooseString
+	assert.Error(t, json.Unmarshal([]byte(`{"number":1}`), &s))
+}
+
+func TestGetHotelImages(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/hotels/6619/details").
+		MatchParam("fields", "^images$").
+		Reply(200).
+		File("fixtures/200-get-hotel-images.json")
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	images, err := client.(*API).GetHotelImages(context.TODO(), 6619)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(images))
+	assert.Equal(t, "00/006619/006619a_hb_ro_088.jpg", images[0].Path)
+}
+
 func TestListCountries(t *testing.T) {
 	defer gock.Off()
 
@@ -79,6 +166,125 @@ func TestListCountries(t *testing.T) {
 	assert.Equal(t, resp.Countries[1].IsoCode, "AE")
 }
 
+func TestHotelsByChain(t *testing.T) {
+	defer gock.Off()
+	defer func(size int) { listHotelsPageSize = size }(listHotelsPageSize)
+	listHotelsPageSize = 2
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/hotels").
+		MatchParams(map[string]string{"from": "1", "to": "2"}).
+		Reply(200).
+		File("fixtures/200-list-hotels-chain-page-1.json")
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/hotels").
+		MatchParams(map[string]string{"from": "3", "to": "4"}).
+		Reply(200).
+		File("fixtures/200-list-hotels-chain-page-2.json")
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	hotels, err := client.(*API).HotelsByChain(context.TODO(), "ACCOR")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(hotels))
+	assert.Equal(t, 6613, hotels[0].Code)
+	assert.Equal(t, 7214, hotels[1].Code)
+	assert.True(t, gock.IsDone())
+}
+
+func TestHotelsByChainFullPages(t *testing.T) {
+	defer gock.Off()
+
+	hotels := make([]map[string]any, 0, maxPageSize)
+	for i := 0; i < maxPageSize; i++ {
+		chain := "MICOP"
+		if i%100 == 0 {
+			chain = "ACCOR"
+		}
+		hotels = append(hotels, map[string]any{"code": i + 1, "chainCode": chain})
+	}
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/hotels").
+		MatchParams(map[string]string{"from": "1", "to": "1000"}).
+		Reply(200).
+		JSON(map[string]any{"from": 1, "to": 1000, "total": 1001, "hotels": hotels})
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/hotels").
+		MatchParams(map[string]string{"from": "1001", "to": "2000"}).
+		Reply(200).
+		JSON(map[string]any{"from": 1001, "to": 1001, "total": 1001, "hotels": []any{
+			map[string]any{"code": 1001, "chainCode": "ACCOR"},
+		}})
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	found, err := client.(*API).HotelsByChain(context.TODO(), "ACCOR")
+	assert.NoError(t, err)
+	assert.Equal(t, 11, len(found))
+	assert.Equal(t, 1001, found[10].Code)
+	assert.True(t, gock.IsDone())
+}
+
+func TestListHotelsInputValidatePage(t *testing.T) {
+	assert.NoError(t, (&ListHotelsInput{From: 1001, To: 2000}).Validate())
+	assert.NoError(t, (&ListHotelsInput{To: 1000}).Validate())
+	assert.Equal(t, ValidationErrors{{FieldName: "To", Max: 1000}}, (&ListHotelsInput{To: 1001}).Validate())
+	assert.Equal(t, ValidationErrors{{FieldName: "To", Max: 2000}}, (&ListHotelsInput{From: 1001, To: 2001}).Validate())
+}
+
+func TestListHotelsEmptyObject(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/hotels").
+		Reply(200).
+		File("fixtures/200-list-hotels-empty-object.json")
+
+	var unknown []string
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"),
+		WithUnknownFieldsNotify(func(fields []string) {
+			unknown = fields
+		}),
+	)
+	resp, err := client.ListHotels(context.TODO(), &ListHotelsInput{})
+	assert.NoError(t, err)
+	assert.NotNil(t, resp.Hotels)
+	assert.Empty(t, resp.Hotels)
+	assert.Nil(t, unknown)
+
+	var availability ListAvailableHotelsResponse
+	assert.NoError(t, json.Unmarshal([]byte(`{"hotels": {"total": 0, "hotels": {}}}`), &availability))
+	assert.Equal(t, 0, len(availability.Hotels.Hotels))
+
+	var details GetHotelDetailsResponse
+	assert.NoError(t, json.Unmarshal([]byte(`{"hotels": [{"code": 1}]}`), &details))
+	assert.Equal(t, 1, details.Hotels[0].Code)
+	assert.Error(t, json.Unmarshal([]byte(`{"hotels": {"code": 1}}`), &details))
+}
+
+func TestListCountriesByIsoCode(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-list-locations-countries-iso.json")
+	assert.NoError(t, err)
+	var resp ListCountriesResp
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	country, ok := resp.ByIsoCode("ES")
+	assert.True(t, ok)
+	assert.Equal(t, "ES", country.Code)
+
+	country, ok = resp.ByIsoCode("gb")
+	assert.True(t, ok)
+	assert.Equal(t, "UK", country.Code)
+	assert.Equal(t, "LONDON", country.States[0].Name)
+
+	country, ok = resp.ByCode("UK")
+	assert.True(t, ok)
+	assert.Equal(t, "GB", country.IsoCode)
+
+	_, ok = resp.ByIsoCode("UK")
+	assert.False(t, ok)
+	_, ok = resp.ByCode("FR")
+	assert.False(t, ok)
+}
+
 func TestListDestinations(t *testing.T) {
 	defer gock.Off()
 
@@ -424,6 +630,7 @@ func TestListRooms(t *testing.T) {
 
 	gock.New("https://api.test.hotelbeds.com").
 		Get("/hotel-content-api/1.0/types/rooms").
+		MatchParam("codes", "^APT.0E,APT.1B$").
 		Reply(200).
 		SetHeader("X-Ratelimit-Limit: 50000", "100").
 		SetHeader("X-Ratelimit-Remaining", "100").
@@ -432,8 +639,9 @@ func TestListRooms(t *testing.T) {
 	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
 	resp, err := client.ListRooms(context.TODO(), &ListRoomsInput{
 		ListInput: ListInput{
-			From: 1,
-			To:   2,
+			Codes: []string{"APT.0E", "APT.1B"},
+			From:  1,
+			To:    2,
 		},
 	})
 	assert.NoError(t, err)
@@ -471,6 +679,41 @@ func TestListRateComments(t *testing.T) {
 	assert.Equal(t, resp.RateComments[1].Code, "100404")
 }
 
+func TestGetRateComments(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/ratecommentdetails").
+		MatchParam("code", "1|694|100403").
+		MatchParam("date", "2024-04-02").
+		Reply(200).
+		File("fixtures/200-get-rate-comment-details.json")
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	rate := CheckRate{
+		Rate: Rate{
+			RateKey:         "20240402|20240403|W|164|6619|TWN.ST|BAR BB FLEX 14|BB||1~1~0||",
+			RateCommentdsID: "1|694|100403",
+		},
+	}
+	resp, err := rate.FetchComments(context.TODO(), client.(*API))
+	assert.NoError(t, err)
+	assert.Equal(t, 694, resp.HotelCode)
+	assert.Equal(t, "100403", resp.Code)
+	assert.Equal(t, "2024-04-02", resp.Date.String())
+	assert.Equal(t, []int{96, 0, 3}, resp.RateCodes)
+	assert.Equal(t, "no refundable rate. no changes, modification are allowed.", resp.Description)
+	assert.True(t, gock.IsDone())
+
+	rate.RateCommentdsID = ""
+	_, err = rate.FetchComments(context.TODO(), client.(*API))
+	assert.Equal(t, &ValidationError{FieldName: "CommentsID", Required: true}, err)
+
+	rate.RateKey = "invalid"
+	_, err = rate.FetchComments(context.TODO(), client.(*API))
+	assert.ErrorIs(t, err, ErrInvalidRateKey)
+}
+
 func TestListSegments(t *testing.T) {
 	defer gock.Off()
 
@@ -520,3 +763,161 @@ func TestListTerminals(t *testing.T) {
 	assert.Equal(t, resp.Terminals[0].Type, "A")
 	assert.Equal(t, resp.Terminals[1].Type, "A")
 }
+
+func TestHotelStars(t *testing.T) {
+	cats := []Category{
+		{Code: "1EST", SimpleCode: SimpleCode1Star, Group: "GRUPO1"},
+		{Code: "4EST", SimpleCode: SimpleCode4Stars, Group: "GRUPO4"},
+	}
+
+	hotel := &Hotel{CategoryCode: "4EST", CategoryGroupCode: "GRUPO4"}
+	stars, ok := hotel.Stars(cats)
+	assert.True(t, ok)
+	assert.Equal(t, 4, stars)
+
+	hotel.CategoryCode = "5LUX"
+	stars, ok = hotel.Stars(cats)
+	assert.False(t, ok)
+	assert.Equal(t, 0, stars)
+}
+
+func TestHotelPhonesE164(t *testing.T) {
+	hotel := &Hotel{
+		Phones: []Phone{
+			{Number: "0034971211100", Type: PhoneTypeHotel},
+			{Number: "+34 971 21 11 01", Type: PhoneTypeBooking},
+			{Number: "0034971211102", Type: PhoneTypeBooking},
+			{Number: "N/A", Type: PhoneTypeFax},
+			{Number: "12", Type: PhoneTypeManagement},
+		},
+	}
+	assert.Equal(t, map[PhoneType]string{
+		PhoneTypeHotel:   "+34971211100",
+		PhoneTypeBooking: "+34971211102",
+	}, hotel.PhonesE164())
+}
+
+func TestAddressFormatted(t *testing.T) {
+	for _, tc := range []struct {
+		address Address
+		want    string
+	}{
+		{address: Address{Content: "Strand", Street: "Strand"}, want: "Strand"},
+		{address: Address{Content: "Sloane Street, London, 17", Street: "Sloane Street, London", Number: "17"}, want: "Sloane Street, London, 17"},
+		{address: Address{Content: "Sloane Street", Street: "Sloane Street", Number: "17"}, want: "Sloane Street, 17"},
+		{address: Address{Content: "Edificio Sol", Street: "Calle Mayor", Number: "5"}, want: "Calle Mayor, 5, Edificio Sol"},
+		{address: Address{Street: "Calle Mayor"}, want: "Calle Mayor"},
+		{address: Address{Content: "Calle Mayor 5 "}, want: "Calle Mayor 5"},
+		{address: Address{}, want: ""},
+	} {
+		assert.Equal(t, tc.want, tc.address.Formatted())
+	}
+}
+
+func TestHotelFullAddress(t *testing.T) {
+	hotel := &Hotel{
+		Address:    Address{Content: "Strand", Street: "Strand"},
+		PostalCode: "WC2R 0EZ",
+		City:       Content{Content: "LONDON"},
+	}
+	assert.Equal(t, "Strand, WC2R 0EZ, LONDON", hotel.FullAddress())
+
+	hotel.Address = Address{Content: "Sloane Street, London, 17", Street: "Sloane Street, London", Number: "17"}
+	assert.Equal(t, "Sloane Street, London, 17, WC2R 0EZ", hotel.FullAddress())
+
+	hotel.Address, hotel.PostalCode = Address{}, ""
+	assert.Equal(t, "LONDON", hotel.FullAddress())
+}
+
+func TestCoordinatesUnmarshalJSON(t *testing.T) {
+	want := Coordinates{Long: -0.160167, Lat: 51.499817}
+	for _, raw := range []string{
+		`{"longitude": -0.160167, "latitude": 51.499817}`,
+		`{"longitude": "-0.160167", "latitude": "51.499817"}`,
+		`{"longitude": -0.160167, "latitude": "51.499817"}`,
+		`"51.499817, -0.160167"`,
+	} {
+		var coords Coordinates
+		assert.NoError(t, json.Unmarshal([]byte(raw), &coords), raw)
+		assert.Equal(t, want, coords, raw)
+	}
+
+	var hotel Hotel
+	assert.NoError(t, json.Unmarshal([]byte(`{"coordinates": null}`), &hotel))
+	assert.Equal(t, Coordinates{}, hotel.Coordinates)
+
+	var coords Coordinates
+	assert.Error(t, json.Unmarshal([]byte(`"51.499817"`), &coords))
+	assert.Error(t, json.Unmarshal([]byte(`{"longitude": "west"}`), &coords))
+}
+
+func TestHotelRoomAccommodates(t *testing.T) {
+	room := HotelRoom{MinPax: 1, MaxPax: 3, MinAdults: 1, MaxAdults: 2, MaxChildren: 2}
+	for _, tc := range []struct {
+		adults, children int
+		want             bool
+	}{
+		{adults: 1, children: 0, want: true},
+		{adults: 2, children: 1, want: true},
+		{adults: 1, children: 2, want: true},
+		{adults: 0, children: 1, want: false},
+		{adults: 3, children: 0, want: false},
+		{adults: 1, children: 3, want: false},
+		{adults: 2, children: 2, want: false},
+		{adults: -1, children: 2, want: false},
+	} {
+		assert.Equal(t, tc.want, room.Accommodates(tc.adults, tc.children), "%d adults, %d children", tc.adults, tc.children)
+	}
+
+	// Room without occupancy data accommodates any party.
+	assert.True(t, HotelRoom{}.Accommodates(6, 4))
+
+	// Zero MaxChildren means the room is adults-only.
+	adultsOnly := HotelRoom{MinPax: 1, MaxPax: 2, MinAdults: 1, MaxAdults: 2}
+	assert.True(t, adultsOnly.Accommodates(2, 0))
+	assert.False(t, adultsOnly.Accommodates(1, 1))
+	assert.False(t, adultsOnly.Accommodates(2, 1))
+
+	data, err := os.ReadFile("fixtures/200-get-hotel-details.json")
+	assert.NoError(t, err)
+	var resp GetHotelDetailsResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+	var adultsOnlyRooms int
+	for _, room := range resp.Hotels[0].Rooms {
+		if room.MaxPax != 0 && room.MaxChildren == 0 {
+			adultsOnlyRooms++
+			assert.False(t, room.Accommodates(room.MinAdults, 1), room.Code)
+		}
+	}
+	assert.NotZero(t, adultsOnlyRooms)
+}
+
+func TestHotelResolveWildcards(t *testing.T) {
+	data, err := os.ReadFile("fixtures/200-get-hotel-details.json")
+	assert.NoError(t, err)
+	var resp GetHotelDetailsResponse
+	assert.NoError(t, json.Unmarshal(data, &resp))
+
+	hotel := resp.Hotels[0]
+	assert.Equal(t, 17, len(hotel.Wildcards))
+
+	wildcard, ok := hotel.MatchWildcard("SUI.DX-KG")
+	assert.True(t, ok)
+	assert.Equal(t, "The Savoy Suite", wildcard.Description.Content)
+	_, ok = hotel.MatchWildcard("SUI.XX")
+	assert.False(t, ok)
+
+	rooms := hotel.ResolveWildcards()
+	assert.Equal(t, 17, len(rooms))
+	assert.Equal(t, "JSU.KG-NM", rooms[0].Code)
+	assert.NotZero(t, rooms[0].MaxPax)
+
+	hotel.Wildcards = append(hotel.Wildcards, HotelWildCard{RoomType: "PH.RV", RoomCode: "PH", CharacteristicCode: "RV"})
+	rooms = hotel.ResolveWildcards()
+	assert.Equal(t, 18, len(rooms))
+	assert.Equal(t, HotelRoom{Code: "PH.RV", Type: "PH", CharacteristicCode: "RV"}, rooms[17])
+
+	wildcard, ok = (&Hotel{Wildcards: []HotelWildCard{{RoomCode: "DBL", CharacteristicCode: "ST"}}}).MatchWildcard("DBL.ST")
+	assert.True(t, ok)
+	assert.Equal(t, "DBL", wildcard.RoomCode)
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2024 0x9ef. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+//go:build go1.23
+
+package hotelbeds
+
+import (
+	"iter"
+	"time"
+)
+
+// DateRange returns sequence of consecutive dates from start up to, but not including, end.
+func DateRange(start, end Datetime) iter.Seq[Datetime] {
+	return func(yield func(Datetime) bool) {
+		for date := time.Time(start); date.Before(time.Time(end)); date = date.AddDate(0, 0, 1) {
+			if !yield(Datetime(date)) {
+				return
+			}
+		}
+	}
+}
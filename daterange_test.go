@@ -0,0 +1,39 @@
+// Copyright (c) 2024 0x9ef. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+//go:build go1.23
+
+package hotelbeds
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDateRange(t *testing.T) {
+	start := Datetime(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+	end := Datetime(time.Date(2024, 4, 8, 0, 0, 0, 0, time.UTC))
+
+	var dates []string
+	for date := range DateRange(start, end) {
+		dates = append(dates, date.String())
+	}
+	assert.Equal(t, []string{"2024-04-01", "2024-04-02", "2024-04-03", "2024-04-04", "2024-04-05", "2024-04-06", "2024-04-07"}, dates)
+
+	// Iteration stops early.
+	dates = dates[:0]
+	for date := range DateRange(start, end) {
+		if date.String() == "2024-04-03" {
+			break
+		}
+		dates = append(dates, date.String())
+	}
+	assert.Equal(t, []string{"2024-04-01", "2024-04-02"}, dates)
+
+	for range DateRange(end, start) {
+		t.Fatal("empty range yields dates")
+	}
+}
@@ -4,18 +4,57 @@
 package hotelbeds
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/0x9ef/clientx"
 	"github.com/shopspring/decimal"
 )
 
 // Amount is an arbitrary-precision decimal.
 type Amount decimal.Decimal
 
+// MarshalJSON encodes amount with two decimal places, as the currency is unknown.
+// Use Money or StringCurrency to format amount with decimal places of the currency.
 func (a Amount) MarshalJSON() ([]byte, error) {
-	return []byte(decimal.Decimal(a).StringFixed(2)), nil
+	return []byte(decimal.Decimal(a).StringFixed(defaultCurrencyDecimalPlaces)), nil
+}
+
+// StringCurrency returns amount rounded to decimal places of the currency, e.g. "1500" for JPY
+// and "12.345" for KWD.
+func (a Amount) StringCurrency(currency string) string {
+	return decimal.Decimal(a).StringFixed(CurrencyDecimalPlaces(currency))
+}
+
+// RoundCurrency returns amount rounded to decimal places of the currency.
+func (a Amount) RoundCurrency(currency string) Amount {
+	return Amount(decimal.Decimal(a).Round(CurrencyDecimalPlaces(currency)))
+}
+
+const defaultCurrencyDecimalPlaces = 2
+
+// currencyDecimalPlaces are ISO 4217 currencies which minor unit differs from two decimal places.
+var currencyDecimalPlaces = map[string]int32{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// CurrencyDecimalPlaces returns number of decimal places of the ISO 4217 currency code,
+// two decimal places are returned for unknown currencies.
+func CurrencyDecimalPlaces(currency string) int32 {
+	if places, ok := currencyDecimalPlaces[strings.ToUpper(currency)]; ok {
+		return places
+	}
+	return defaultCurrencyDecimalPlaces
 }
 
 func (a *Amount) UnmarshalJSON(data []byte) error {
@@ -27,15 +66,37 @@ func (a *Amount) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Content is a localized text. Content API returns it as an object with language code,
+// while Booking API returns names as plain strings, which are decoded into Content as well.
 type Content struct {
 	Content      string `json:"content"`
 	LanguageCode string `json:"languageCode"`
 }
 
+func (c *Content) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return fmt.Errorf("failed to parse Content: %w", err)
+		}
+		*c = Content{Content: str}
+		return nil
+	}
+	type content Content
+	return json.Unmarshal(data, (*content)(c))
+}
+
+// Coordinate is a latitude or longitude, which is sent either as a JSON number or string.
+// Null and empty string are decoded as zero.
 type Coordinate float64
 
 func (c *Coordinate) UnmarshalJSON(data []byte) error {
-	f, err := strconv.ParseFloat(trimUnescapeQuotes(data), 64)
+	str := strings.TrimSpace(string(data))
+	if str == "null" || str == `""` {
+		*c = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(trimUnescapeQuotes([]byte(str))), 64)
 	if err != nil {
 		return fmt.Errorf("failed to parse Coordinate: %w", err)
 	}
@@ -110,6 +171,7 @@ func (o *Order) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Distance is a distance in kilometers, see ConvertDistance for other units.
 type Distance float64
 
 func (d *Distance) UnmarshalJSON(data []byte) error {
@@ -121,6 +183,31 @@ func (d *Distance) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Kilometers returns distance in kilometers.
+func (d Distance) Kilometers() float64 {
+	return float64(d)
+}
+
+// Miles returns distance in miles.
+func (d Distance) Miles() float64 {
+	return float64(ConvertDistance(d, UnitKilometers, UnitMiles))
+}
+
+// kilometersPerMile is the length of international mile.
+const kilometersPerMile = 1.609344
+
+// ConvertDistance converts d measured in from units into to units.
+// Distance is returned as is if any of units is unknown.
+func ConvertDistance(d Distance, from, to Unit) Distance {
+	switch {
+	case from == UnitKilometers && to == UnitMiles:
+		return d / kilometersPerMile
+	case from == UnitMiles && to == UnitKilometers:
+		return d * kilometersPerMile
+	}
+	return d
+}
+
 type Radius int
 
 func (r Radius) MarshalJSON() ([]byte, error) {
@@ -161,6 +248,61 @@ func (r FloatRate) Float() float64 {
 	return float64(r)
 }
 
+// LooseString is a string, which is sent either as a JSON string, number or boolean.
+// Null is decoded as empty string.
+type LooseString string
+
+func (s *LooseString) UnmarshalJSON(data []byte) error {
+	str := strings.TrimSpace(string(data))
+	switch {
+	case str == "null":
+		*s = ""
+	case strings.HasPrefix(str, `"`):
+		var v string
+		if err := json.Unmarshal(data, &v); err != nil {
+			return fmt.Errorf("failed to parse LooseString: %w", err)
+		}
+		*s = LooseString(v)
+	case str == "true" || str == "false":
+		*s = LooseString(str)
+	default:
+		if _, err := strconv.ParseFloat(str, 64); err != nil {
+			return fmt.Errorf("failed to parse LooseString: %s is not a string or number", str)
+		}
+		*s = LooseString(str)
+	}
+	return nil
+}
+
+func (s LooseString) String() string {
+	return string(s)
+}
+
+// LooseSlice is a slice, which is sent either as a JSON array or as an empty
+// object "{}" when there are no elements (e.g. in empty responses).
+type LooseSlice[T any] []T
+
+func (s *LooseSlice[T]) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) != 0 && data[0] == '{' {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return fmt.Errorf("failed to parse LooseSlice: %w", err)
+		}
+		if len(obj) != 0 {
+			return fmt.Errorf("failed to parse LooseSlice: non-empty object")
+		}
+		*s = LooseSlice[T]{}
+		return nil
+	}
+	var elems []T
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return err
+	}
+	*s = elems
+	return nil
+}
+
 func trimUnescapeQuotes(data []byte) string {
 	str, err := strconv.Unquote(string(data))
 	if err != nil {
@@ -171,3 +313,233 @@ func trimUnescapeQuotes(data []byte) string {
 	}
 	return str
 }
+
+// marshalWithExtra marshals v as a JSON object and appends extra fields to it in sorted order.
+// Extra fields can't override fields of v.
+func marshalWithExtra(v any, extra map[string]any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		if _, ok := fields[key]; ok {
+			return nil, fmt.Errorf("extra field %q conflicts with the input field", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf := bytes.NewBuffer(data[:len(data)-1])
+	for i, key := range keys {
+		value, err := json.Marshal(extra[key])
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal extra field %q: %w", key, err)
+		}
+		if i > 0 || len(fields) > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// unmarshalerDecoder decodes JSON responses with the custom Unmarshaler,
+// requests are encoded with encoding/json.
+type unmarshalerDecoder struct {
+	unmarshaler Unmarshaler
+}
+
+func (d unmarshalerDecoder) Encode(w io.Writer, v any) error {
+	return clientx.JSONEncoderDecoder.Encode(w, v)
+}
+
+func (d unmarshalerDecoder) Decode(r io.Reader, dst any) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return d.unmarshaler.Unmarshal(data, dst)
+}
+
+// notifyDecoder decodes JSON responses as usual (or with unmarshaler, if set) and reports
+// fields which are not mapped to the destination structure and enum values unknown to the client.
+type notifyDecoder struct {
+	fieldsNotify UnknownFieldsNotifyFunc
+	enumNotify   UnknownEnumNotifyFunc
+	unmarshaler  Unmarshaler
+}
+
+func (d notifyDecoder) Encode(w io.Writer, v any) error {
+	return clientx.JSONEncoderDecoder.Encode(w, v)
+}
+
+func (d notifyDecoder) Decode(r io.Reader, dst any) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if d.unmarshaler != nil {
+		if err := d.unmarshaler.Unmarshal(data, dst); err != nil {
+			return err
+		}
+	} else if err := json.NewDecoder(bytes.NewReader(data)).Decode(dst); err != nil {
+		return err
+	}
+
+	if d.enumNotify != nil {
+		notifyUnknownEnums(reflect.ValueOf(dst), d.enumNotify)
+	}
+	if d.fieldsNotify == nil {
+		return nil
+	}
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	fields := make(map[string]struct{})
+	collectUnknownFields(raw, reflect.TypeOf(dst), "", fields)
+	if len(fields) != 0 {
+		paths := make([]string, 0, len(fields))
+		for path := range fields {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		d.fieldsNotify(paths)
+	}
+	return nil
+}
+
+// enum is implemented by string enums (e.g. BookingStatus, PaymentType, PhoneType),
+// which know their valid values.
+type enum interface {
+	Valid() bool
+}
+
+var enumType = reflect.TypeOf((*enum)(nil)).Elem()
+
+// notifyUnknownEnums walks through decoded value v and invokes notify with
+// type name and value of each non-empty enum, which isn't valid.
+func notifyUnknownEnums(v reflect.Value, notify UnknownEnumNotifyFunc) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			notifyUnknownEnums(v.Elem(), notify)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				notifyUnknownEnums(v.Field(i), notify)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			notifyUnknownEnums(v.Index(i), notify)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			notifyUnknownEnums(iter.Value(), notify)
+		}
+	case reflect.String:
+		if v.Len() != 0 && v.Type().Implements(enumType) && !v.Interface().(enum).Valid() {
+			notify(v.Type().Name(), v.String())
+		}
+	}
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// collectUnknownFields walks through decoded JSON value and adds paths
+// of object keys that have no corresponding field in t to fields.
+// Values of types with custom JSON decoding are not inspected, except slices
+// (e.g. LooseSlice), which elements are decoded as usual.
+func collectUnknownFields(v any, t reflect.Type, path string, fields map[string]struct{}) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Slice && reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Map:
+			for key, elem := range v {
+				collectUnknownFields(elem, t.Elem(), joinPath(path, key), fields)
+			}
+		case reflect.Struct:
+			for key, elem := range v {
+				field, ok := jsonField(t, key)
+				if !ok {
+					fields[joinPath(path, key)] = struct{}{}
+					continue
+				}
+				collectUnknownFields(elem, field.Type, joinPath(path, key), fields)
+			}
+		}
+	case []any:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for _, elem := range v {
+			collectUnknownFields(elem, t.Elem(), path+"[]", fields)
+		}
+	}
+}
+
+// jsonField returns field of struct t which is decoded from JSON key. Like encoding/json,
+// keys are matched case-insensitively and fields of embedded structs are promoted.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	var (
+		fallback reflect.StructField
+		found    bool
+	)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if embedded := field.Type; name == "" && field.Anonymous {
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if f, ok := jsonField(embedded, key); ok && !found {
+					fallback, found = f, true
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if name == key {
+			return field, true
+		}
+		if !found && strings.EqualFold(name, key) {
+			fallback, found = field, true
+		}
+	}
+	return fallback, found
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2024 0x9ef. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+package hotelbeds
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/0x9ef/clientx"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertDistance(t *testing.T) {
+	d := Distance(16.09344)
+	assert.Equal(t, 16.09344, d.Kilometers())
+	assert.InDelta(t, 10, d.Miles(), 1e-9)
+
+	assert.InDelta(t, 10, float64(ConvertDistance(d, UnitKilometers, UnitMiles)), 1e-9)
+	assert.InDelta(t, 16.09344, float64(ConvertDistance(10, UnitMiles, UnitKilometers)), 1e-9)
+	assert.InDelta(t, 25, float64(ConvertDistance(ConvertDistance(25, UnitMiles, UnitKilometers), UnitKilometers, UnitMiles)), 1e-9)
+
+	assert.Equal(t, Distance(5), ConvertDistance(5, UnitMiles, UnitMiles))
+	assert.Equal(t, Distance(5), ConvertDistance(5, UnitKilometers, UnitKilometers))
+	assert.Equal(t, Distance(5), ConvertDistance(5, Unit("ft"), UnitMiles))
+}
+
+func TestCoordinateUnmarshalJSON(t *testing.T) {
+	for raw, want := range map[string]Coordinate{
+		`51.499817`:                 51.499817,
+		`"42.98302410000000000000"`: 42.9830241,
+		`" -0.160167 "`:             -0.160167,
+		`""`:                        0,
+		`null`:                      0,
+	} {
+		c := Coordinate(1)
+		assert.NoError(t, json.Unmarshal([]byte(raw), &c), raw)
+		assert.Equal(t, want, c, raw)
+	}
+
+	var c Coordinate
+	assert.Error(t, json.Unmarshal([]byte(`"north"`), &c))
+}
+
+func TestAmountStringCurrency(t *testing.T) {
+	a := Amount(decimal.RequireFromString("1500.4567"))
+	assert.Equal(t, "1500", a.StringCurrency("JPY"))
+	assert.Equal(t, "1500.457", a.StringCurrency("KWD"))
+	assert.Equal(t, "1500.46", a.StringCurrency("EUR"))
+	assert.Equal(t, "1500.46", a.StringCurrency("XYZ"))
+	assert.Equal(t, "1500", a.StringCurrency("jpy"))
+
+	assert.Equal(t, "1500", decimal.Decimal(a.RoundCurrency("JPY")).String())
+	assert.Equal(t, "1500.457", decimal.Decimal(a.RoundCurrency("KWD")).String())
+
+	data, err := json.Marshal(a)
+	assert.NoError(t, err)
+	assert.Equal(t, "1500.46", string(data))
+}
+
+func TestContentUnmarshalJSON(t *testing.T) {
+	for raw, want := range map[string]Content{
+		`{"content":"4 STARS","languageCode":"ENG"}`: {Content: "4 STARS", LanguageCode: "ENG"},
+		`"4 STARS"`:      {Content: "4 STARS"},
+		`"West \"End\""`: {Content: `West "End"`},
+		`null`:           {},
+	} {
+		var c Content
+		assert.NoError(t, json.Unmarshal([]byte(raw), &c), raw)
+		assert.Equal(t, want, c, raw)
+	}
+
+	var c Content
+	assert.Error(t, json.Unmarshal([]byte(`1`), &c))
+}
+
+func BenchmarkDecodeHotelDetails(b *testing.B) {
+	data, err := os.ReadFile("fixtures/200-get-hotel-details.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for name, decoder := range map[string]clientx.EncoderDecoder{
+		"default":     clientx.JSONEncoderDecoder,
+		"unmarshaler": unmarshalerDecoder{unmarshaler: UnmarshalerFunc(json.Unmarshal)},
+	} {
+		decoder := decoder
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				var resp GetHotelDetailsResponse
+				if err := decoder.Decode(bytes.NewReader(data), &resp); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -7,8 +7,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"regexp"
 	"strings"
+
+	"github.com/shopspring/decimal"
 )
 
 // ErrorCode represents code of HotelBeds error.
@@ -20,6 +24,8 @@ const (
 	ErrorCodeInvalidRequest ErrorCode = "INVALID_REQUEST"
 	ErrorCodeInvalidData    ErrorCode = "INVALID_DATA"
 	ErrorCodeProduct        ErrorCode = "PRODUCT_ERROR"
+	ErrorCodeRateLimit      ErrorCode = "RATE_LIMIT_EXCEEDED"
+	ErrorCodeQuota          ErrorCode = "QUOTA_EXCEEDED"
 )
 
 var (
@@ -32,11 +38,14 @@ var (
 	ErrSystem            = errors.New("system error")
 	ErrInvalidRequest    = errors.New("invalid request")
 	ErrInvalidData       = errors.New("invalid data")
+	ErrEnvironmentNotSet = errors.New("environment is not set")
+	ErrCurrencyMismatch  = errors.New("currency mismatch")
 
 	// Product errors.
 	ErrAllotmentExceeded                                = errors.New("allotment exceeded")
 	ErrInsufficientAllotment                            = errors.New("insufficient allotment")
 	ErrPriceHasIncreased                                = errors.New("price has increased")
+	ErrToleranceExceeded                                = errors.New("tolerance exceeded")
 	ErrPriceHasChanged                                  = errors.New("price has changed")
 	ErrStopSales                                        = errors.New("stop sales")
 	ErrBookingDoesNotExist                              = errors.New("booking does not exist")
@@ -59,12 +68,89 @@ type Error struct {
 	// Our internal variables.
 	StatusCode  int  `json:"-"`
 	IsRetryable bool `json:"-"`
+	// Cause is the sentinel error (e.g. ErrStopSales) or the detailed error (*ToleranceExceededError)
+	// of the message, so it can be checked with errors.Is. It's nil if the message isn't recognized.
+	Cause error `json:"-"`
 }
 
 func (e *Error) Error() string {
 	return fmt.Sprintf("code=%s,statusCode=%d,message=%s", e.Code, e.StatusCode, e.Message)
 }
 
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// ServerID returns ID of the server which handled the request,
+// it should be reported to Hotelbeds support along with the Token.
+func (e *Error) ServerID() string {
+	if e.Audit == nil {
+		return ""
+	}
+	return e.Audit.ServerID
+}
+
+// Token returns token of the request, which identifies it in Hotelbeds logs.
+func (e *Error) Token() string {
+	if e.Audit == nil {
+		return ""
+	}
+	return e.Audit.Token
+}
+
+// ToleranceExceededError is returned on confirm when the price difference between
+// checkrates and confirm exceeds ConfirmBookingInput.Tolerance. Booking might be
+// re-quoted with a higher tolerance. Amounts are zero if they aren't reported.
+type ToleranceExceededError struct {
+	OldAmount Amount
+	NewAmount Amount
+	// Currency of the amounts, used to format them. The API doesn't report it,
+	// it might be set to the currency of the rate (two decimal places are used if empty).
+	Currency string
+}
+
+func (e *ToleranceExceededError) Error() string {
+	return fmt.Sprintf("tolerance exceeded: old amount %s, new amount %s",
+		e.OldAmount.StringCurrency(e.Currency), e.NewAmount.StringCurrency(e.Currency))
+}
+
+func (e *ToleranceExceededError) Is(target error) bool {
+	return target == ErrToleranceExceeded
+}
+
+var (
+	// toleranceExceededPattern matches the message of the API, e.g. "Price has changed and the difference
+	// exceeds the tolerance of 2.00%. Old price: 899.23, new price: 950.10".
+	toleranceExceededPattern = regexp.MustCompile(`(?i)\bexceeds\s+the\s+tolerance\b`)
+	// oldPricePattern and newPricePattern match labelled amounts of the message, in any order.
+	oldPricePattern = regexp.MustCompile(`(?i)\bold\s+price:\s*(\d+(?:\.\d+)?)`)
+	newPricePattern = regexp.MustCompile(`(?i)\bnew\s+price:\s*(\d+(?:\.\d+)?)`)
+)
+
+// newToleranceExceededError parses old and new amounts from the error message
+// (see toleranceExceededPattern).
+func newToleranceExceededError(msg string) *ToleranceExceededError {
+	var err ToleranceExceededError
+	if m := oldPricePattern.FindStringSubmatch(msg); m != nil {
+		err.OldAmount = Amount(decimal.RequireFromString(m[1]))
+	}
+	if m := newPricePattern.FindStringSubmatch(msg); m != nil {
+		err.NewAmount = Amount(decimal.RequireFromString(m[1]))
+	}
+	return &err
+}
+
+// IsClientError reports whether error is caused by the request (4xx status code),
+// so request should be fixed rather than retried.
+func (e *Error) IsClientError() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500
+}
+
+// IsServerError reports whether error is caused by the API (5xx status code).
+func (e *Error) IsServerError() bool {
+	return e.StatusCode >= 500 && e.StatusCode < 600
+}
+
 // IsErrorCode checks if error contains specified code.
 func IsErrorCode(err error, code ErrorCode) bool {
 	if err, ok := err.(*Error); ok {
@@ -73,7 +159,9 @@ func IsErrorCode(err error, code ErrorCode) bool {
 	return false
 }
 
-// IsErrorRetryable checks if error is retryable.
+// IsErrorRetryable checks if error is retryable. Server side (5xx) errors are retryable,
+// except for ConfirmBooking: booking might be confirmed despite the error, so the retry
+// could create a duplicate (see SafeConfirmBookingRetry).
 func IsErrorRetryable(err error) bool {
 	if err, ok := err.(*Error); ok {
 		return err.IsRetryable
@@ -81,43 +169,85 @@ func IsErrorRetryable(err error) bool {
 	return false
 }
 
-type shortError struct {
-	Error string `json:"error"`
+// IsPreProcessingError checks if request failed before it was sent to the server
+// (e.g. DNS lookup failed or connection was refused), so it's safe to retry any request.
+func IsPreProcessingError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// errorBody is either short (gateway) error {"error": "message"},
+// or API error with code and message, which might be nested into "error" object.
+type errorBody struct {
+	Audit   *AuditData      `json:"auditData"`
+	Code    ErrorCode       `json:"code"`
+	Message string          `json:"message"`
+	Error   json.RawMessage `json:"error"`
 }
 
 func decodeError(resp *http.Response) error {
-	var shortErr shortError
-	if err := json.NewDecoder(resp.Body).Decode(&shortErr); err == nil {
-		isRetryable, _ := isRetryableError[decodeErrorMessage(shortErr.Error)]
-		return &Error{
-			Message:     shortErr.Error,
-			StatusCode:  resp.StatusCode,
-			IsRetryable: isRetryable,
-		}
+	var body errorBody
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return ErrUndefined
 	}
-
-	var longErr Error
-	if err := json.NewDecoder(resp.Body).Decode(&longErr); err == nil {
-		isRetryable, _ := isRetryableError[decodeErrorMessage(shortErr.Error)]
-		return &Error{
-			Audit:       longErr.Audit,
-			Code:        longErr.Code,
-			Message:     longErr.Message,
-			StatusCode:  resp.StatusCode,
-			IsRetryable: isRetryable,
+	if len(body.Error) != 0 {
+		var nested struct {
+			Audit   *AuditData `json:"auditData"`
+			Code    ErrorCode  `json:"code"`
+			Message string     `json:"message"`
+		}
+		if err := json.Unmarshal(body.Error, &body.Message); err != nil {
+			if err := json.Unmarshal(body.Error, &nested); err != nil {
+				return ErrUndefined
+			}
+			body.Code, body.Message = nested.Code, nested.Message
+			if body.Audit == nil {
+				body.Audit = nested.Audit
+			}
 		}
 	}
 
-	return ErrUndefined
+	sentinel := decodeErrorMessage(body.Message)
+	code := body.Code
+	if code == "" {
+		code = errorCodes[sentinel]
+	}
+	apiErr := &Error{
+		Audit:       body.Audit,
+		Code:        code,
+		Message:     body.Message,
+		StatusCode:  resp.StatusCode,
+		IsRetryable: isRetryableError[sentinel] || (resp.StatusCode >= 500 && !isConfirmBookingAttempt(resp, nil)),
+	}
+	// Stop sales aren't flagged by rates, so ErrStopSales is reported only when the rate is booked.
+	apiErr.Cause = sentinel
+	if sentinel == ErrToleranceExceeded {
+		apiErr.Cause = newToleranceExceededError(body.Message)
+	}
+	return apiErr
 }
 
 var (
+	// isRetryableError lists retryable errors of client side,
+	// server side (5xx) errors are retryable except for ConfirmBooking.
 	isRetryableError = map[error]bool{
 		ErrRateLimitExceeded: true,
 		ErrQuotaExceeded:     true,
 	}
+
+	// errorCodes maps sentinel errors of short (gateway) responses
+	// to the ErrorCode, as such responses don't contain code field.
+	errorCodes = map[error]ErrorCode{
+		ErrRateLimitExceeded: ErrorCodeRateLimit,
+		ErrQuotaExceeded:     ErrorCodeQuota,
+	}
 )
 
+// decodeErrorMessage returns the sentinel error of the message, nil if it isn't recognized.
 func decodeErrorMessage(msg string) error {
 	switch {
 	case errorContains(msg, ErrExternal):
@@ -138,11 +268,13 @@ func decodeErrorMessage(msg string) error {
 		return ErrAllotmentExceeded
 	case errorContains(msg, ErrInsufficientAllotment):
 		return ErrInsufficientAllotment
+	case toleranceExceededPattern.MatchString(msg):
+		return ErrToleranceExceeded
 	case errorContains(msg, ErrPriceHasIncreased):
 		return ErrPriceHasIncreased
 	case errorContains(msg, ErrPriceHasChanged):
 		return ErrPriceHasChanged
-	case errorContains(msg, ErrStopSales):
+	case isStopSalesMessage(msg):
 		return ErrStopSales
 	case errorContains(msg, ErrBookingDoesNotExist):
 		return ErrBookingDoesNotExist
@@ -165,8 +297,18 @@ func decodeErrorMessage(msg string) error {
 	case errorContains(msg, ErrReservationUnreachable):
 		return ErrReservationUnreachable
 	default:
-		return errors.New("undefined error")
+		return nil
+	}
+}
+
+// isStopSalesMessage reports whether the message is about the stop sales,
+// which is spelled inconsistently (e.g. "Stop sales", "STOP-SALE", "stopSales").
+func isStopSalesMessage(msg string) bool {
+	msg = strings.ToLower(msg)
+	for _, sep := range []string{" ", "-", "_"} {
+		msg = strings.ReplaceAll(msg, sep, "")
 	}
+	return strings.Contains(msg, "stopsale")
 }
 
 func errorContains(s string, err error) bool {
@@ -179,8 +321,73 @@ type ValidationError struct {
 	Min       int
 	Max       int
 	Allow     []string
+	// Err is the underlying error, if the field failed with an error other than the ValidationError.
+	Err error
 }
 
 func (e *ValidationError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
 	return fmt.Sprintf("field=%s,required=%t,min=%d,max=%d,allow=[%s]", e.FieldName, e.Required, e.Min, e.Max, strings.Join(e.Allow, ","))
 }
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors aggregates all validation errors of the input,
+// so they can be fixed at once. Validate methods always return ValidationErrors,
+// even if the only field is invalid; errors.As(err, &validationErr) with
+// *ValidationError target finds the first of them.
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i := range errs {
+		msgs[i] = errs[i].Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of errs matches target.
+func (errs ValidationErrors) Is(target error) bool {
+	for i := range errs {
+		if errors.Is(&errs[i], target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of errs that matches target.
+func (errs ValidationErrors) As(target any) bool {
+	for i := range errs {
+		if errors.As(&errs[i], target) {
+			return true
+		}
+	}
+	return false
+}
+
+// add appends validation errors of err, nested ValidationErrors are flattened.
+// Other errors are kept as Err of the ValidationError.
+func (errs *ValidationErrors) add(err error) {
+	switch err := err.(type) {
+	case nil:
+	case *ValidationError:
+		*errs = append(*errs, *err)
+	case ValidationErrors:
+		*errs = append(*errs, err...)
+	default:
+		*errs = append(*errs, ValidationError{Err: err})
+	}
+}
+
+// err returns nil if there are no errors and ValidationErrors otherwise.
+func (errs ValidationErrors) err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
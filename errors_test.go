@@ -0,0 +1,249 @@
+// Copyright (c) 2024 0x9ef. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+package hotelbeds
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestErrorCodeRateLimit(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		Reply(429).
+		JSON(map[string]string{"error": "Rate limits exceeded"})
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	_, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.Error(t, err)
+	assert.True(t, IsErrorCode(err, ErrorCodeRateLimit))
+	assert.False(t, IsErrorCode(err, ErrorCodeQuota))
+	assert.True(t, IsErrorRetryable(err))
+	assert.ErrorIs(t, err, ErrRateLimitExceeded)
+}
+
+func TestErrorCodeQuota(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		Reply(403).
+		JSON(map[string]string{"error": "Quota Exceeded"})
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	_, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.Error(t, err)
+	assert.True(t, IsErrorCode(err, ErrorCodeQuota))
+	assert.False(t, IsErrorCode(err, ErrorCodeRateLimit))
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+}
+
+func TestErrorCause(t *testing.T) {
+	defer gock.Off()
+
+	for _, tc := range []struct {
+		message string
+		cause   error
+	}{
+		{message: "Booking does not exist", cause: ErrBookingDoesNotExist},
+		{message: "Minimum stay violated: 3 nights", cause: ErrMinimumStayViolated},
+		{message: "Insufficient allotment", cause: ErrInsufficientAllotment},
+		{message: "Something went wrong"},
+	} {
+		gock.New("https://api.test.hotelbeds.com").
+			Get("/hotel-api/1.0/bookings/207-12306403").
+			Reply(400).
+			JSON(map[string]any{"error": map[string]string{"code": "INVALID_REQUEST", "message": tc.message}})
+
+		client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+		_, err := client.GetBooking(context.TODO(), "207-12306403")
+		assert.Error(t, err, tc.message)
+		assert.Equal(t, tc.cause, err.(*Error).Cause, tc.message)
+		if tc.cause != nil {
+			assert.ErrorIs(t, err, tc.cause, tc.message)
+		}
+	}
+}
+
+func TestErrorStatusClass(t *testing.T) {
+	for _, tc := range []struct {
+		statusCode  int
+		message     string
+		clientError bool
+		serverError bool
+		retryable   bool
+	}{
+		{statusCode: 400, message: "Invalid request", clientError: true},
+		{statusCode: 404, message: "Booking does not exist", clientError: true},
+		{statusCode: 429, message: "Rate limits exceeded", clientError: true, retryable: true},
+		{statusCode: 500, message: "System error", serverError: true, retryable: true},
+		{statusCode: 503, message: "no healthy upstream", serverError: true, retryable: true},
+	} {
+		func() {
+			defer gock.Off()
+
+			gock.New("https://api.test.hotelbeds.com").
+				Get("/hotel-content-api/1.0/types/boards").
+				Reply(tc.statusCode).
+				JSON(map[string]string{"error": tc.message})
+
+			client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+			_, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+
+			var apiErr *Error
+			assert.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tc.clientError, apiErr.IsClientError(), tc.statusCode)
+			assert.Equal(t, tc.serverError, apiErr.IsServerError(), tc.statusCode)
+			assert.Equal(t, tc.retryable, IsErrorRetryable(err), tc.statusCode)
+		}()
+	}
+}
+
+func TestErrorToleranceExceeded(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.2/bookings").
+		Reply(409).
+		File("fixtures/409-confirm-booking-tolerance-exceeded.json")
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	_, err := client.ConfirmBooking(context.TODO(), &ConfirmBookingInput{
+		Holder:          Holder{Name: "HolderFirstName", Surname: "HolderLastName"},
+		ClientReference: "IntegrationAgency",
+		Rooms: []ConfirmBookingRoom{
+			{RateKey: "20240406|20240408|W|506|712986|DBL.DX|ID_B2B_26|BB||1~2~0||"},
+		},
+		Tolerance: Amount(decimal.NewFromInt(2)),
+	})
+	assert.True(t, IsErrorCode(err, ErrorCodeProduct))
+	assert.ErrorIs(t, err, ErrToleranceExceeded)
+	assert.NotErrorIs(t, err, ErrPriceHasChanged)
+
+	var tolErr *ToleranceExceededError
+	assert.ErrorAs(t, err, &tolErr)
+	assert.Equal(t, "899.23", decimal.Decimal(tolErr.OldAmount).StringFixed(2))
+	assert.Equal(t, "950.10", decimal.Decimal(tolErr.NewAmount).StringFixed(2))
+	assert.EqualError(t, tolErr, "tolerance exceeded: old amount 899.23, new amount 950.10")
+	tolErr.Currency = "JPY"
+	assert.EqualError(t, tolErr, "tolerance exceeded: old amount 899, new amount 950")
+	assert.NotNil(t, err.(*Error).Audit)
+}
+
+func TestToleranceExceededMessage(t *testing.T) {
+	for _, tc := range []struct {
+		message  string
+		sentinel error
+		old, new string
+	}{
+		{
+			message:  "Price has changed and the difference exceeds the tolerance of 2.00%. Old price: 899.23, new price: 950.10",
+			sentinel: ErrToleranceExceeded,
+			old:      "899.23",
+			new:      "950.10",
+		},
+		{
+			message:  "Price has changed and the difference exceeds the tolerance of 5%. New price: 1020.5, old price: 960",
+			sentinel: ErrToleranceExceeded,
+			old:      "960.00",
+			new:      "1020.50",
+		},
+		{
+			message:  "The difference exceeds the tolerance of 2.00%",
+			sentinel: ErrToleranceExceeded,
+			old:      "0.00",
+			new:      "0.00",
+		},
+		{message: "Tolerance 150.00 is out of range 0-100"},
+	} {
+		sentinel := decodeErrorMessage(tc.message)
+		assert.Equal(t, tc.sentinel, sentinel, tc.message)
+		if sentinel != ErrToleranceExceeded {
+			continue
+		}
+		err := newToleranceExceededError(tc.message)
+		assert.Equal(t, tc.old, decimal.Decimal(err.OldAmount).StringFixed(2), tc.message)
+		assert.Equal(t, tc.new, decimal.Decimal(err.NewAmount).StringFixed(2), tc.message)
+	}
+}
+
+func TestErrorStopSales(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.2/bookings").
+		Reply(409).
+		File("fixtures/409-confirm-booking-stop-sales.json")
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	_, err := client.ConfirmBooking(context.TODO(), &ConfirmBookingInput{
+		Holder:          Holder{Name: "HolderFirstName", Surname: "HolderLastName"},
+		ClientReference: "IntegrationAgency",
+		Rooms: []ConfirmBookingRoom{
+			{RateKey: "20240406|20240408|W|506|712986|DBL.DX|ID_B2B_26|BB||1~2~0||"},
+		},
+	})
+	assert.True(t, IsErrorCode(err, ErrorCodeProduct))
+	assert.ErrorIs(t, err, ErrStopSales)
+	assert.False(t, IsErrorRetryable(err))
+
+	for _, msg := range []string{"STOP-SALE for the hotel", "Rate is on stopSales", "stop_sales"} {
+		assert.Equal(t, ErrStopSales, decodeErrorMessage(msg), msg)
+	}
+}
+
+func TestErrorAuditData(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.0/hotels").
+		Reply(400).
+		File("fixtures/400-list-available-hotels-invalid-data.json")
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	_, err := client.ListAvailableHotels(context.TODO(), &ListAvailableHotelsInput{
+		Stay:        Stay{CheckIn: "2024-03-01", CheckOut: "2024-03-02"},
+		Occupancies: []Occupancy{{Rooms: 1, Adults: 1}},
+		Hotels:      FilterHotel{HotelCodes: []int{6619}},
+	})
+	assert.True(t, IsErrorCode(err, ErrorCodeInvalidData))
+
+	apiErr := err.(*Error)
+	assert.NotNil(t, apiErr.Audit)
+	assert.Equal(t, "ip-10-214-46-98.eu-central-1.compute.internal#A+", apiErr.ServerID())
+	assert.Equal(t, "A9C1D52F0E8B4F6A8E7D3C2B1A0F9E8D", apiErr.Token())
+	assert.Equal(t, "2024-03-14 09:12:03.417", time.Time(apiErr.Audit.Timestamp).Format("2006-01-02 15:04:05.000"))
+
+	assert.Empty(t, (&Error{}).ServerID())
+	assert.Empty(t, (&Error{}).Token())
+}
+
+func TestValidationErrors(t *testing.T) {
+	var errs ValidationErrors
+	errs.add(nil)
+	assert.NoError(t, errs.err())
+
+	errs.add(&ValidationError{FieldName: "Stay.CheckIn", Required: true})
+	errs.add(ErrRateKeyMismatch)
+	err := errs.err()
+	assert.Equal(t, ValidationErrors{
+		{FieldName: "Stay.CheckIn", Required: true},
+		{Err: ErrRateKeyMismatch},
+	}, err)
+	assert.EqualError(t, err, "field=Stay.CheckIn,required=true,min=0,max=0,allow=[]; rateKey mismatch")
+	assert.ErrorIs(t, err, ErrRateKeyMismatch)
+
+	var validationErr *ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "Stay.CheckIn", validationErr.FieldName)
+}
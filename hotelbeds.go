@@ -4,12 +4,19 @@
 package hotelbeds
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/0x9ef/clientx"
@@ -22,6 +29,9 @@ type (
 		options   *Options
 		apiKey    string
 		apiSecret string
+		// clockOffset is the difference between the server and the local clock
+		// in nanoseconds, it's updated atomically if ClockResync is enabled.
+		clockOffset int64
 	}
 
 	Client interface {
@@ -34,13 +44,111 @@ type (
 		DefaultHeaders http.Header
 		Limit          *clientx.OptionRateLimit
 		Retry          *clientx.OptionRetry
+		RetryNotify    RetryNotifyFunc
+		// RetryJitter randomizes retry wait times, none by default.
+		RetryJitter Jitter
+		// PathPrefix is prepended to the path of every endpoint.
+		PathPrefix string
+		// Language is the default language of responses.
+		Language string
+		// CreationUser is the default agent name of confirmed bookings.
+		CreationUser string
+		// Clock returns current time, time.Now is used by default.
+		Clock func() time.Time
+		// SignatureResolution is the granularity of X-Signature timestamp, one second by default.
+		SignatureResolution time.Duration
+		// UnknownFieldsNotify is invoked with JSON fields of the response that weren't decoded.
+		UnknownFieldsNotify UnknownFieldsNotifyFunc
+		// UnknownEnumNotify is invoked with enum values of the response that are unknown to the client.
+		UnknownEnumNotify UnknownEnumNotifyFunc
+		// RoundTrippers wrap http.DefaultTransport, the first one is the outermost.
+		RoundTrippers []RoundTripperFunc
+		// LatencyObserver is invoked with server process time and round trip time of each request.
+		LatencyObserver LatencyObserverFunc
+		// Accept and ContentType headers of requests, JSON is used by default.
+		Accept      string
+		ContentType string
+		// Environment is the host of the API, EnvironmentTest is used by default.
+		Environment Environment
+		// RequireEnvironment makes NewClient fail if Environment isn't set explicitly.
+		RequireEnvironment bool
+		// ClockResync enables a single resend of requests rejected (403) because of the X-Signature
+		// timestamp, the clock is adjusted by the server Date header and the signature is regenerated.
+		ClockResync bool
+		// Unmarshaler decodes JSON responses, encoding/json is used by default.
+		Unmarshaler Unmarshaler
 	}
+
+	// Unmarshaler decodes JSON data into v, e.g. jsoniter.ConfigCompatibleWithStandardLibrary.
+	// It must support json.Unmarshaler implementations of the response types.
+	Unmarshaler interface {
+		Unmarshal(data []byte, v any) error
+	}
+
+	// UnmarshalerFunc is an adapter to use function (e.g. json.Unmarshal of other library) as Unmarshaler.
+	UnmarshalerFunc func(data []byte, v any) error
+
+	// LatencyObserverFunc is invoked after each request with route template of the endpoint
+	// (e.g. /hotel-api/1.0/bookings/{bookingId}), process time reported by the server
+	// in AuditData (zero if not reported) and round trip time of the request.
+	LatencyObserverFunc func(endpoint string, serverProcess time.Duration, roundTrip time.Duration)
+
+	// UnknownFieldsNotifyFunc is invoked with sorted paths (e.g. "hotels.hotels[].name")
+	// of response JSON fields that have no corresponding field in the response structure.
+	UnknownFieldsNotifyFunc func(fields []string)
+
+	// UnknownEnumNotifyFunc is invoked with type name (e.g. "BookingStatus") and value
+	// of each enum in the response, which is unknown to the client.
+	UnknownEnumNotifyFunc func(enumType, value string)
+
+	// RoundTripperFunc wraps the next http.RoundTripper, e.g. to collect metrics or traces.
+	RoundTripperFunc func(next http.RoundTripper) http.RoundTripper
+
+	// Environment is the base URL of the API.
+	Environment string
+
+	// Jitter is the strategy to randomize retry wait times, so retries
+	// of many clients failed at the same time aren't synchronized.
+	Jitter int
+
+	// RetryNotifyFunc is invoked before each retry sleep with attempt number,
+	// error of the failed request and the time to wait till the next attempt.
+	RetryNotifyFunc func(attempt int, err error, wait time.Duration)
+)
+
+const (
+	EnvironmentTest       Environment = "https://api.test.hotelbeds.com"
+	EnvironmentProduction Environment = "https://api.hotelbeds.com"
+)
+
+const (
+	// JitterNone uses wait times of the retry function as is.
+	JitterNone Jitter = iota
+	// JitterFull waits random time between zero and the wait time.
+	JitterFull
+	// JitterEqual waits half of the wait time plus random time up to the other half.
+	JitterEqual
 )
 
 var _ Client = (*API)(nil)
 
 // New returns new API with provided apiKey, apiSecret, applies all options.
+// It never fails, MustSetEnvironment is checked only by NewClient.
 func New(apiKey, apiSecret string, opts ...Option) Client {
+	return newAPI(apiKey, apiSecret, opts...)
+}
+
+// NewClient returns new API with provided apiKey, apiSecret, applies all options.
+// Returns ErrEnvironmentNotSet if MustSetEnvironment is used without WithEnvironment.
+func NewClient(apiKey, apiSecret string, opts ...Option) (Client, error) {
+	api := newAPI(apiKey, apiSecret, opts...)
+	if api.options.RequireEnvironment && api.options.Environment == "" {
+		return nil, ErrEnvironmentNotSet
+	}
+	return api, nil
+}
+
+func newAPI(apiKey, apiSecret string, opts ...Option) *API {
 	api := &API{
 		apiKey:    apiKey,
 		apiSecret: apiSecret,
@@ -51,41 +159,418 @@ func New(apiKey, apiSecret string, opts ...Option) Client {
 		opt(&options)
 	}
 
+	if options.Clock == nil {
+		options.Clock = time.Now
+	}
 	api.options = &options
-	api.API = clientx.NewAPI(api.options.toClientxOptions()...)
+	var roundTrippers []RoundTripperFunc
+	if options.ClockResync {
+		roundTrippers = append(roundTrippers, api.resyncClock)
+	}
+	api.API = clientx.NewAPI(api.options.toClientxOptions(roundTrippers...)...)
 	return api
 }
 
-func (opts *Options) toClientxOptions(options ...Option) []clientx.Option {
-	clientxOptions := make([]clientx.Option, 0, len(options)+1)
-	clientxOptions = append(clientxOptions, clientx.WithBaseURL("https://api.test.hotelbeds.com"))
+// toClientxOptions converts options to clientx options, internal round trippers
+// of the client are placed after RoundTrippers of the options.
+func (opts *Options) toClientxOptions(internal ...RoundTripperFunc) []clientx.Option {
+	clientxOptions := make([]clientx.Option, 0, 4)
+	environment := opts.Environment
+	if environment == "" {
+		environment = EnvironmentTest
+	}
+	clientxOptions = append(clientxOptions, clientx.WithBaseURL(string(environment)))
 	if opts.Limit != nil {
 		clientxOptions = append(clientxOptions,
 			clientx.WithRateLimit(opts.Limit.Limit, opts.Limit.Burst, opts.Limit.Per))
 	}
 	if opts.Retry != nil {
+		fn, conditions := opts.Retry.Fn, opts.Retry.Conditions
+		if opts.RetryJitter != JitterNone {
+			fn = opts.RetryJitter.wrap(fn)
+		}
+		if opts.RetryNotify != nil {
+			fn, conditions = newRetryNotifier(opts.RetryNotify).wrap(fn, conditions)
+		}
 		clientxOptions = append(clientxOptions,
-			clientx.WithRetry(opts.Retry.MaxAttempts, opts.Retry.MinWaitTime, opts.Retry.MaxWaitTime, opts.Retry.Fn, opts.Retry.Conditions...))
+			clientx.WithRetry(opts.Retry.MaxAttempts, opts.Retry.MinWaitTime, opts.Retry.MaxWaitTime, fn, conditions...))
+	}
+	roundTrippers := append(opts.RoundTrippers[:len(opts.RoundTrippers):len(opts.RoundTrippers)], internal...)
+	if opts.LatencyObserver != nil {
+		// Latency is observed as close to the network as possible.
+		roundTrippers = append(roundTrippers, opts.LatencyObserver.wrap)
+	}
+	if len(roundTrippers) != 0 {
+		transport := http.DefaultTransport
+		for i := len(roundTrippers) - 1; i >= 0; i-- {
+			transport = roundTrippers[i](transport)
+		}
+		clientxOptions = append(clientxOptions, clientx.WithHTTPClient(&http.Client{Transport: transport}))
 	}
 	return clientxOptions
 }
 
-func (api *API) buildHeaders() http.Header {
+// wrap returns retry function, which randomizes wait times of fn
+// (clientx.ExponentalBackoff by default).
+func (j Jitter) wrap(fn clientx.RetryFunc) clientx.RetryFunc {
+	if fn == nil {
+		fn = clientx.ExponentalBackoff
+	}
+	return func(attempt int, min, max time.Duration) time.Duration {
+		wait := fn(attempt, min, max)
+		if wait <= 0 {
+			return wait
+		}
+		switch j {
+		case JitterFull:
+			return time.Duration(rand.Int63n(int64(wait) + 1))
+		case JitterEqual:
+			half := wait / 2
+			return wait - half + time.Duration(rand.Int63n(int64(half)+1))
+		}
+		return wait
+	}
+}
+
+// wrap returns round tripper, which measures request latency and reads
+// process time from AuditData of the response.
+func (observe LatencyObserverFunc) wrap(next http.RoundTripper) http.RoundTripper {
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return resp, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		roundTrip := time.Since(start)
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		observe(routeTemplate(req.URL.Path), decodeAuditData(body).SafeProcessTime(), roundTrip)
+		return resp, nil
+	})
+}
+
+// routeTemplates replace identifiers in the paths of endpoints,
+// so latency is observed per endpoint rather than per hotel or booking.
+var routeTemplates = []struct {
+	pattern  *regexp.Regexp
+	template string
+}{
+	{regexp.MustCompile(`/hotel-api/1\.0/bookings/[^/]+$`), "/hotel-api/1.0/bookings/{bookingId}"},
+	{regexp.MustCompile(`/hotel-content-api/1\.0/hotels/[^/]+/details$`), "/hotel-content-api/1.0/hotels/{hotelCodes}/details"},
+}
+
+// routeTemplate returns the route template of the path, path prefix is kept.
+func routeTemplate(path string) string {
+	for _, route := range routeTemplates {
+		if loc := route.pattern.FindStringIndex(path); loc != nil {
+			return path[:loc[0]] + route.template
+		}
+	}
+	return path
+}
+
+// resyncClock returns round tripper, which resends the request once if it's rejected because
+// of the X-Signature timestamp (see timestampErrorPattern) and the server clock, taken from the
+// Date header, differs from the local one by more than the signature resolution. The clock offset
+// is stored, so the signature of the resend and of all subsequent requests is corrected. Requests
+// with credentials overridden by ContextWithCredentials aren't resent and don't affect the clock.
+// Like retries of WithRetry, the resend doesn't wait for the rate limiter, it's reported to RetryNotify.
+func (api *API) resyncClock(next http.RoundTripper) http.RoundTripper {
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		if _, ok := req.Context().Value(credentialsKey{}).(credentials); ok {
+			return next.RoundTrip(req)
+		}
+		getBody := req.GetBody
+		if getBody == nil && req.Body != nil && req.Body != http.NoBody {
+			// Body is recorded while it's sent, so it's available for the resend.
+			recorder := &bodyRecorder{ReadCloser: req.Body}
+			req = req.Clone(req.Context())
+			req.Body = recorder
+			getBody = recorder.replay
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusForbidden {
+			return resp, err
+		}
+		serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+		if err != nil {
+			return resp, nil
+		}
+		resolution := api.options.SignatureResolution
+		if resolution < time.Second {
+			resolution = time.Second
+		}
+		if skew := serverTime.Sub(api.now()); skew <= resolution && skew >= -resolution {
+			return resp, nil
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		apiErr, ok := decodeResponseError(resp, body).(*Error)
+		if !ok || !timestampErrorPattern.MatchString(apiErr.Message) {
+			return resp, nil
+		}
+
+		retry := req.Clone(req.Context())
+		if req.Body != nil && req.Body != http.NoBody {
+			if retry.Body, err = getBody(); err != nil {
+				// Body can't be read again, so the request can't be resent.
+				return resp, nil
+			}
+		}
+		atomic.StoreInt64(&api.clockOffset, int64(serverTime.Sub(api.options.Clock())))
+		if api.options.RetryNotify != nil {
+			api.options.RetryNotify(1, apiErr, 0)
+		}
+		retry.Header.Set("X-Signature", api.signature(api.apiKey, api.apiSecret))
+		return next.RoundTrip(retry)
+	})
+}
+
+// timestampErrorPattern matches messages of 403 responses, which reject the X-Signature
+// because of its timestamp (e.g. "Request signature has expired"). Invalid signature
+// (e.g. wrong secret) isn't matched, as it isn't fixed by the clock resync.
+var timestampErrorPattern = regexp.MustCompile(`(?i)\b(signature|timestamp)\s+(has\s+)?expired\b|\binvalid\s+timestamp\b|\btimestamp\s+(is\s+)?(invalid|out\s+of\s+range)\b`)
+
+// bodyRecorder records the request body while it's read by the transport.
+type bodyRecorder struct {
+	io.ReadCloser
+	buf bytes.Buffer
+	eof bool
+}
+
+func (r *bodyRecorder) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.buf.Write(p[:n])
+	if err == io.EOF {
+		r.eof = true
+	}
+	return n, err
+}
+
+// replay returns the recorded body. The rest of the body, which wasn't read by the transport
+// (e.g. the server responded before it was sent), is read from the original body if possible.
+func (r *bodyRecorder) replay() (io.ReadCloser, error) {
+	if !r.eof {
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+	return io.NopCloser(bytes.NewReader(r.buf.Bytes())), nil
+}
+
+type roundTripper func(req *http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// retryNotifier remembers the error of the last retried request
+// to pass it into RetryNotifyFunc when the next wait time is calculated.
+type retryNotifier struct {
+	mu      sync.Mutex
+	lastErr error
+	notify  RetryNotifyFunc
+}
+
+func newRetryNotifier(notify RetryNotifyFunc) *retryNotifier {
+	return &retryNotifier{notify: notify}
+}
+
+func (n *retryNotifier) wrap(fn clientx.RetryFunc, conditions []clientx.RetryCond) (clientx.RetryFunc, []clientx.RetryCond) {
+	if fn == nil {
+		fn = clientx.ExponentalBackoff
+	}
+	wrappedFn := func(attempt int, min, max time.Duration) time.Duration {
+		wait := fn(attempt, min, max)
+		n.mu.Lock()
+		err := n.lastErr
+		n.mu.Unlock()
+		n.notify(attempt, err, wait)
+		return wait
+	}
+	wrappedCond := func(resp *http.Response, err error) bool {
+		for _, cond := range conditions {
+			if cond(resp, err) {
+				n.mu.Lock()
+				n.lastErr = retryError(resp, err)
+				n.mu.Unlock()
+				return true
+			}
+		}
+		return false
+	}
+	return wrappedFn, []clientx.RetryCond{wrappedCond}
+}
+
+// retryError returns err of the request or decodes error from response body.
+// Response body is restored, so it can be read again.
+func retryError(resp *http.Response, err error) error {
+	if err != nil || resp == nil {
+		return err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return decodeResponseError(resp, body)
+}
+
+// decodeResponseError decodes error from the already read response body. Request and headers
+// of the response are kept, as the error depends on them (e.g. ConfirmBooking isn't retryable).
+func decodeResponseError(resp *http.Response, body []byte) error {
+	return decodeError(&http.Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Request:    resp.Request,
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	})
+}
+
+// now returns current time of the configured clock, adjusted
+// by the server clock offset if ClockResync is enabled.
+func (api *API) now() time.Time {
+	return api.options.Clock().Add(time.Duration(atomic.LoadInt64(&api.clockOffset)))
+}
+
+// Unmarshal calls f(data, v).
+func (f UnmarshalerFunc) Unmarshal(data []byte, v any) error {
+	return f(data, v)
+}
+
+// decoder returns response decoder, which uses Unmarshaler if set and reports
+// unknown fields and enum values if UnknownFieldsNotify or UnknownEnumNotify is set.
+func (api *API) decoder() clientx.EncoderDecoder {
+	if api.options.UnknownFieldsNotify != nil || api.options.UnknownEnumNotify != nil {
+		return notifyDecoder{
+			fieldsNotify: api.options.UnknownFieldsNotify,
+			enumNotify:   api.options.UnknownEnumNotify,
+			unmarshaler:  api.options.Unmarshaler,
+		}
+	}
+	if api.options.Unmarshaler != nil {
+		return unmarshalerDecoder{unmarshaler: api.options.Unmarshaler}
+	}
+	return clientx.JSONEncoderDecoder
+}
+
+// path returns endpoint path with configured path prefix.
+func (api *API) path(endpoint string) string {
+	return api.options.PathPrefix + endpoint
+}
+
+// minRequestDeadline is the minimal time left before context deadline
+// to send a request. Requests with less time left fail fast, because
+// they most likely won't be processed in time.
+const minRequestDeadline = 100 * time.Millisecond
+
+// withRequestHeaders builds headers right before the request is sent (after
+// waiting in the rate limiter), so the X-Signature is always fresh.
+func (api *API) withRequestHeaders() clientx.RequestOption {
+	return func(req *http.Request) error {
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < minRequestDeadline {
+			return context.DeadlineExceeded
+		}
+		req.Header = api.buildHeaders(api.credentials(req.Context()))
+		return nil
+	}
+}
+
+// credentials returns apiKey and apiSecret overridden by ContextWithCredentials or credentials of the client.
+func (api *API) credentials(ctx context.Context) (apiKey, apiSecret string) {
+	if creds, ok := ctx.Value(credentialsKey{}).(credentials); ok {
+		return creds.apiKey, creds.apiSecret
+	}
+	return api.apiKey, api.apiSecret
+}
+
+// mediaTypeJSON is the default Accept and Content-Type of requests.
+const mediaTypeJSON = "application/json"
+
+// headerAPIKey is the API key header in the casing documented by Hotelbeds. It's set directly
+// in the header map, as http.Header.Set would canonicalize it to "Api-Key", while map keys are
+// sent on the wire as is (HTTP/1.x).
+const headerAPIKey = "Api-key"
+
+// buildHeaders returns headers of the request. Accept-Encoding isn't set, so the transport
+// requests gzip compression itself and transparently decompresses the response.
+func (api *API) buildHeaders(apiKey, apiSecret string) http.Header {
+	accept, contentType := api.options.Accept, api.options.ContentType
+	if accept == "" {
+		accept = mediaTypeJSON
+	}
+	if contentType == "" {
+		contentType = mediaTypeJSON
+	}
 	return http.Header{
-		"Accept":          []string{"application/json"},
-		"Accept-Encoding": []string{"application/json"},
-		"Content-Type":    []string{"application/json"},
-		"Api-key":         []string{api.apiKey},
-		"X-Signature":     []string{api.hashSignature()},
+		"Accept":       []string{accept},
+		"Content-Type": []string{contentType},
+		headerAPIKey:   []string{apiKey},
+		"X-Signature":  []string{api.signature(apiKey, apiSecret)},
+	}
+}
+
+type (
+	credentialsKey struct{}
+	credentials    struct {
+		apiKey    string
+		apiSecret string
 	}
+)
+
+// ContextWithCredentials returns context, which overrides apiKey and apiSecret of the client
+// for requests made with it, so a single client can serve multiple accounts.
+// Credentials of the client are used for requests without override.
+func ContextWithCredentials(ctx context.Context, apiKey, apiSecret string) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, credentials{apiKey: apiKey, apiSecret: apiSecret})
 }
 
+// hashSignature returns X-Signature of the client credentials.
 func (api *API) hashSignature() string {
+	return api.signature(api.apiKey, api.apiSecret)
+}
+
+// signature returns X-Signature, which is SHA256 of apiKey, apiSecret and Unix timestamp
+// in seconds. Hotelbeds accepts signatures which timestamp differs from the server time
+// by a few seconds only, so the clock should be in sync.
+func (api *API) signature(apiKey, apiSecret string) string {
 	hasher := sha256.New()
-	hasher.Write([]byte(fmt.Sprintf("%s%s%d", api.apiKey, api.apiSecret, time.Now().Unix())))
+	hasher.Write([]byte(signaturePayload(apiKey, apiSecret, api.signatureTimestamp(api.now()))))
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
+// SignatureInputs returns the payload which is signed at the time now and the resulting
+// X-Signature, so integrators can audit what is sent. The secret is masked in the payload
+// with asterisks, so the payload is safe to log.
+func (api *API) SignatureInputs(now time.Time) (payload string, hash string) {
+	timestamp := api.signatureTimestamp(now)
+	hasher := sha256.New()
+	hasher.Write([]byte(signaturePayload(api.apiKey, api.apiSecret, timestamp)))
+	return signaturePayload(api.apiKey, strings.Repeat("*", len(api.apiSecret)), timestamp), hex.EncodeToString(hasher.Sum(nil))
+}
+
+// signatureTimestamp truncates now to SignatureResolution.
+func (api *API) signatureTimestamp(now time.Time) time.Time {
+	if resolution := api.options.SignatureResolution; resolution > time.Second {
+		return now.Truncate(resolution)
+	}
+	return now
+}
+
+func signaturePayload(apiKey, apiSecret string, timestamp time.Time) string {
+	return fmt.Sprintf("%s%s%d", apiKey, apiSecret, timestamp.Unix())
+}
+
 func joinInts[T constraints.Integer](values []int) string {
 	var sb strings.Builder
 	for i := range values {
@@ -0,0 +1,117 @@
+// Copyright (c) 2024 0x9ef. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+package hotelbeds
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestRequestDeadlineExceeded(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		Reply(200).
+		File("fixtures/200-list-types-boards.json")
+
+	// Limiter allows a single request per 200ms, so the second request waits
+	// in the limiter till there is less than minRequestDeadline left.
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"),
+		WithRateLimit(1, 1, 200*time.Millisecond))
+	_, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	_, err = client.ListBoards(ctx, &ListBoardsInput{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestContextWithCredentials(t *testing.T) {
+	defer gock.Off()
+
+	now := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	for _, creds := range []struct{ key, secret string }{
+		{key: "tenant-key", secret: "tenant-secret"},
+		{key: "key", secret: "secret"},
+	} {
+		creds := creds
+		signature := sha256.Sum256([]byte(creds.key + creds.secret + "1711972800"))
+		gock.New("https://api.test.hotelbeds.com").
+			Get("/hotel-content-api/1.0/types/boards").
+			AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+				return req.Header["Api-key"][0] == creds.key, nil
+			}).
+			MatchHeader("X-Signature", hex.EncodeToString(signature[:])).
+			Reply(200).
+			File("fixtures/200-list-types-boards.json")
+	}
+
+	client := New("key", "secret", WithClock(func() time.Time { return now }))
+	_, err := client.ListBoards(ContextWithCredentials(context.TODO(), "tenant-key", "tenant-secret"), &ListBoardsInput{})
+	assert.NoError(t, err)
+
+	// Client credentials are used without override.
+	_, err = client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+}
+
+func TestAPIKeyHeaderCasing(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	// Raw request is read from the connection, as http.Server canonicalizes header keys.
+	headers := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var lines []string
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil || line == "\r\n" {
+				break
+			}
+			lines = append(lines, strings.TrimRight(line, "\r\n"))
+		}
+		headers <- lines
+		io.WriteString(conn, "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Length: 13\r\nConnection: close\r\n\r\n{\"boards\":[]}")
+	}()
+
+	client := New("key", "secret", WithEnvironment(Environment("http://"+ln.Addr().String())))
+	_, err = client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+	assert.Contains(t, <-headers, "Api-key: key")
+}
+
+func TestSignatureInputs(t *testing.T) {
+	now := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	api := New("key", "secret", WithClock(func() time.Time { return now })).(*API)
+
+	payload, hash := api.SignatureInputs(now)
+	assert.Equal(t, "key******1711972800", payload)
+	assert.NotContains(t, payload, "secret")
+
+	sum := sha256.Sum256([]byte("keysecret1711972800"))
+	assert.Equal(t, hex.EncodeToString(sum[:]), hash)
+	assert.Equal(t, api.hashSignature(), hash)
+}
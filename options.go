@@ -5,6 +5,7 @@ package hotelbeds
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/0x9ef/clientx"
@@ -22,6 +23,24 @@ func WithRetry(maxAttempts int, minWaitTime, maxWaitTime time.Duration, f client
 	}
 }
 
+// WithRetryNotify sets callback that is invoked before each retry sleep.
+// Has effect only when retries are enabled with WithRetry.
+func WithRetryNotify(f func(attempt int, err error, wait time.Duration)) Option {
+	return func(o *Options) {
+		o.RetryNotify = f
+	}
+}
+
+// WithRetryJitter sets strategy to randomize retry wait times.
+// Has effect only when retries are enabled with WithRetry.
+func WithRetryJitter(jitter Jitter) Option {
+	return func(o *Options) {
+		o.RetryJitter = jitter
+	}
+}
+
+// WithRateLimit limits requests to limit per the period, allowing bursts of up to burst requests.
+// The token bucket starts full, so the first burst of requests after start isn't delayed.
 func WithRateLimit(limit int, burst int, per time.Duration) Option {
 	return func(o *Options) {
 		o.Limit = &clientx.OptionRateLimit{
@@ -37,3 +56,132 @@ func WithHeaders(set http.Header) Option {
 		o.DefaultHeaders = set
 	}
 }
+
+// WithAccept overrides Accept header of requests, e.g. for debugging.
+// Responses are decoded as JSON regardless of it.
+func WithAccept(mediaType string) Option {
+	return func(o *Options) {
+		o.Accept = mediaType
+	}
+}
+
+// WithContentType overrides Content-Type header of requests, e.g. for debugging.
+// Request bodies are encoded as JSON regardless of it.
+func WithContentType(mediaType string) Option {
+	return func(o *Options) {
+		o.ContentType = mediaType
+	}
+}
+
+// WithEnvironment sets host of the API, EnvironmentTest is used by default.
+func WithEnvironment(env Environment) Option {
+	return func(o *Options) {
+		o.Environment = env
+	}
+}
+
+// MustSetEnvironment requires the environment to be set explicitly with WithEnvironment,
+// so production deployment doesn't silently use the test host. NewClient returns ErrEnvironmentNotSet
+// otherwise, New doesn't check it.
+func MustSetEnvironment() Option {
+	return func(o *Options) {
+		o.RequireEnvironment = true
+	}
+}
+
+// WithPathPrefix sets prefix prepended to the path of every endpoint,
+// e.g. when the API is accessed through the gateway.
+func WithPathPrefix(prefix string) Option {
+	return func(o *Options) {
+		if prefix = strings.Trim(prefix, "/"); prefix != "" {
+			prefix = "/" + prefix
+		}
+		o.PathPrefix = prefix
+	}
+}
+
+// WithLanguage sets default language of responses, which is used
+// when language isn't specified in the request input.
+func WithLanguage(lang string) Option {
+	return func(o *Options) {
+		o.Language = lang
+	}
+}
+
+// WithDefaultCreationUser sets agent name of confirmed bookings,
+// which is used when ConfirmBookingInput.CreationUser isn't specified.
+func WithDefaultCreationUser(user string) Option {
+	return func(o *Options) {
+		o.CreationUser = user
+	}
+}
+
+// WithClock sets function that returns current time. It's used to calculate
+// X-Signature and by helpers which depend on current time (e.g. cache expiry).
+func WithClock(clock func() time.Time) Option {
+	return func(o *Options) {
+		o.Clock = clock
+	}
+}
+
+// WithUnknownFieldsNotify sets callback that is invoked with response JSON fields
+// which aren't known to the client. Response is decoded as usual, so it may be used
+// to detect API changes without breaking requests.
+func WithUnknownFieldsNotify(f func(fields []string)) Option {
+	return func(o *Options) {
+		o.UnknownFieldsNotify = f
+	}
+}
+
+// WithUnknownEnumNotify sets callback that is invoked with type name and value of each enum
+// (e.g. BookingStatus, PaymentType, PhoneType) in the response, which is unknown to the client,
+// e.g. to log values introduced by the API. The value is decoded as is.
+func WithUnknownEnumNotify(f func(enumType, value string)) Option {
+	return func(o *Options) {
+		o.UnknownEnumNotify = f
+	}
+}
+
+// WithUnmarshaler sets decoder of JSON responses, e.g. faster drop-in replacement of encoding/json
+// for large content payloads. Error responses are always decoded with encoding/json.
+func WithUnmarshaler(u Unmarshaler) Option {
+	return func(o *Options) {
+		o.Unmarshaler = u
+	}
+}
+
+// WithSignatureResolution sets granularity of X-Signature timestamp, so all requests
+// sent within the same resolution window share the signature. Signature timestamp is
+// in seconds, so resolution below a second has no effect. As Hotelbeds accepts only signatures
+// a few seconds old, resolution shouldn't exceed that. Current time is taken from WithClock.
+func WithSignatureResolution(resolution time.Duration) Option {
+	return func(o *Options) {
+		o.SignatureResolution = resolution
+	}
+}
+
+// WithClockResync enables retry of requests rejected with 403 because of the X-Signature
+// timestamp. The clock is adjusted by the Date header of the server response, so
+// signatures of the retry and subsequent requests are valid despite local clock skew.
+func WithClockResync() Option {
+	return func(o *Options) {
+		o.ClockResync = true
+	}
+}
+
+// WithLatencyObserver sets callback that is invoked after each request with the route template,
+// process time reported by the server and the round trip time, so server-side and network
+// latency can be compared.
+func WithLatencyObserver(f func(endpoint string, serverProcess time.Duration, roundTrip time.Duration)) Option {
+	return func(o *Options) {
+		o.LatencyObserver = f
+	}
+}
+
+// WithRoundTripper adds middleware wrapping the transport of the client.
+// Might be specified multiple times, the first middleware is the outermost one.
+func WithRoundTripper(f func(next http.RoundTripper) http.RoundTripper) Option {
+	return func(o *Options) {
+		o.RoundTrippers = append(o.RoundTrippers, f)
+	}
+}
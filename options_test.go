@@ -0,0 +1,656 @@
+// Copyright (c) 2024 0x9ef. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+package hotelbeds
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestWithRateLimitStartupBurst(t *testing.T) {
+	defer gock.Off()
+
+	const burst = 5
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		Times(burst).
+		Reply(200).
+		File("fixtures/200-list-types-boards.json")
+
+	// The next token is available only in a second.
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"),
+		WithRateLimit(1, burst, time.Second))
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < burst; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.True(t, gock.IsDone())
+}
+
+func TestWithRetryNotify(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		Persist().
+		Reply(429).
+		JSON(map[string]string{"error": "Rate limits exceeded"})
+
+	var attempts []int
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"),
+		WithRetry(3, time.Millisecond, 5*time.Millisecond, nil, func(resp *http.Response, err error) bool {
+			return resp != nil && resp.StatusCode == http.StatusTooManyRequests
+		}),
+		WithRetryNotify(func(attempt int, err error, wait time.Duration) {
+			attempts = append(attempts, attempt)
+			assert.True(t, IsErrorCode(err, ErrorCodeRateLimit))
+			assert.LessOrEqual(t, wait, 5*time.Millisecond)
+		}),
+	)
+	_, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.True(t, IsErrorCode(err, ErrorCodeRateLimit))
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+}
+
+func TestWithRetryNotifyConfirmBooking(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.2/bookings").
+		Reply(500).
+		JSON(map[string]string{"error": "System error"})
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.2/bookings").
+		Reply(200).
+		File("fixtures/200-confirm-booking.json")
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		Reply(500).
+		JSON(map[string]string{"error": "System error"})
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		Reply(200).
+		File("fixtures/200-list-types-boards.json")
+
+	var notified []error
+	// Confirmation is retried on purpose to check the error passed to RetryNotify.
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"),
+		WithRetry(2, time.Millisecond, 5*time.Millisecond, nil, func(resp *http.Response, err error) bool {
+			return resp != nil && resp.StatusCode >= 500
+		}),
+		WithRetryNotify(func(attempt int, err error, wait time.Duration) {
+			notified = append(notified, err)
+		}),
+	)
+	_, err := client.ConfirmBooking(context.TODO(), &ConfirmBookingInput{
+		Holder:          Holder{Name: "HolderFirstName", Surname: "HolderLastName"},
+		ClientReference: "IntegrationAgency",
+		Rooms: []ConfirmBookingRoom{
+			{RateKey: "20240406|20240408|W|506|712986|DBL.DX|ID_B2B_26|BB||1~2~0||"},
+		},
+	})
+	assert.NoError(t, err)
+	_, err = client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(notified))
+	assert.ErrorIs(t, notified[0], ErrSystem)
+	assert.False(t, IsErrorRetryable(notified[0]))
+	assert.ErrorIs(t, notified[1], ErrSystem)
+	assert.True(t, IsErrorRetryable(notified[1]))
+}
+
+func TestWithRetryJitter(t *testing.T) {
+	backoff := func(attempt int, min, max time.Duration) time.Duration {
+		return min << attempt
+	}
+	for _, tc := range []struct {
+		jitter   Jitter
+		minRatio float64
+	}{
+		{jitter: JitterNone, minRatio: 1},
+		{jitter: JitterFull, minRatio: 0},
+		{jitter: JitterEqual, minRatio: 0.5},
+	} {
+		fn := tc.jitter.wrap(backoff)
+		for attempt := 1; attempt <= 5; attempt++ {
+			want := backoff(attempt, time.Millisecond, time.Second)
+			for i := 0; i < 100; i++ {
+				wait := fn(attempt, time.Millisecond, time.Second)
+				assert.GreaterOrEqual(t, wait, time.Duration(float64(want)*tc.minRatio), tc.jitter)
+				assert.LessOrEqual(t, wait, want, tc.jitter)
+			}
+		}
+	}
+
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		Persist().
+		Reply(429).
+		JSON(map[string]string{"error": "Rate limits exceeded"})
+
+	var waits []time.Duration
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"),
+		WithRetry(3, time.Millisecond, 10*time.Millisecond, backoff, func(resp *http.Response, err error) bool {
+			return resp != nil && resp.StatusCode == http.StatusTooManyRequests
+		}),
+		WithRetryJitter(JitterEqual),
+		WithRetryNotify(func(attempt int, err error, wait time.Duration) {
+			waits = append(waits, wait)
+			assert.GreaterOrEqual(t, wait, (time.Millisecond<<attempt)/2)
+			assert.LessOrEqual(t, wait, time.Millisecond<<attempt)
+		}),
+	)
+	_, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.True(t, IsErrorCode(err, ErrorCodeRateLimit))
+	assert.Equal(t, 3, len(waits))
+}
+
+func TestWithPathPrefix(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotelbeds/hotel-content-api/1.0/types/boards").
+		Reply(200).
+		File("fixtures/200-list-types-boards.json")
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotelbeds/hotel-api/1.0/checkrates").
+		Reply(200).
+		File("fixtures/200-list-checkrates.json")
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"), WithPathPrefix("/hotelbeds/"))
+	boards, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, boards.Boards)
+
+	rates, err := client.ListCheckRates(context.TODO(), &ListCheckRatesInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, 6619, rates.Hotel.Code)
+	assert.True(t, gock.IsDone())
+}
+
+func TestWithClock(t *testing.T) {
+	defer gock.Off()
+
+	now := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	signature := sha256.Sum256([]byte("key" + "secret" + "1711972800"))
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		MatchHeader("X-Signature", hex.EncodeToString(signature[:])).
+		Reply(200).
+		File("fixtures/200-list-types-boards.json")
+
+	client := New("key", "secret", WithClock(func() time.Time { return now }))
+	_, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+}
+
+type countingTransport struct {
+	next     http.RoundTripper
+	requests []string
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.requests = append(c.requests, req.URL.Path)
+	return c.next.RoundTrip(req)
+}
+
+func TestWithLatencyObserver(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/hotels/6613,6619/details").
+		Reply(200).
+		Delay(20 * time.Millisecond).
+		File("fixtures/200-get-hotel-details.json")
+
+	var (
+		endpoints     []string
+		serverProcess time.Duration
+		roundTrip     time.Duration
+	)
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"),
+		WithLatencyObserver(func(endpoint string, process time.Duration, rt time.Duration) {
+			endpoints = append(endpoints, endpoint)
+			serverProcess, roundTrip = process, rt
+		}),
+	)
+	resp, err := client.GetHotelDetails(context.TODO(), []int{6613, 6619}, &GetHotelDetailsInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(resp.Hotels))
+
+	assert.Equal(t, []string{"/hotel-content-api/1.0/hotels/{hotelCodes}/details"}, endpoints)
+	assert.Equal(t, 266*time.Millisecond, serverProcess)
+	assert.GreaterOrEqual(t, roundTrip, 20*time.Millisecond)
+
+	// Audit data isn't the first field of the response.
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-api/1.0/bookings/207-12306403").
+		Reply(200).
+		BodyString(`{"booking":{"reference":"207-12306403","status":"CONFIRMED"},"auditData":{"processTime":"41"}}`)
+	_, err = client.GetBooking(context.TODO(), "207-12306403")
+	assert.NoError(t, err)
+	assert.Equal(t, "/hotel-api/1.0/bookings/{bookingId}", endpoints[1])
+	assert.Equal(t, 41*time.Millisecond, serverProcess)
+}
+
+func TestRouteTemplate(t *testing.T) {
+	assert.Equal(t, "/hotel-api/1.0/hotels", routeTemplate("/hotel-api/1.0/hotels"))
+	assert.Equal(t, "/hotel-api/1.2/bookings", routeTemplate("/hotel-api/1.2/bookings"))
+	assert.Equal(t, "/hotel-api/1.0/bookings", routeTemplate("/hotel-api/1.0/bookings"))
+	assert.Equal(t, "/proxy/hotel-api/1.0/bookings/{bookingId}", routeTemplate("/proxy/hotel-api/1.0/bookings/102-123456"))
+	assert.Equal(t, "/hotel-content-api/1.0/hotels/{hotelCodes}/details", routeTemplate("/hotel-content-api/1.0/hotels/1/details"))
+}
+
+func TestWithRoundTripper(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		Reply(200).
+		File("fixtures/200-list-types-boards.json")
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.0/checkrates").
+		Reply(200).
+		File("fixtures/200-list-checkrates.json")
+
+	var (
+		order    []string
+		counting = &countingTransport{}
+	)
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"),
+		WithRoundTripper(func(next http.RoundTripper) http.RoundTripper {
+			order = append(order, "outer")
+			counting.next = next
+			return counting
+		}),
+		WithRoundTripper(func(next http.RoundTripper) http.RoundTripper {
+			order = append(order, "inner")
+			return next
+		}),
+	)
+	_, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+	_, err = client.ListCheckRates(context.TODO(), &ListCheckRatesInput{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"inner", "outer"}, order)
+	assert.Equal(t, []string{"/hotel-content-api/1.0/types/boards", "/hotel-api/1.0/checkrates"}, counting.requests)
+	assert.True(t, gock.IsDone())
+}
+
+func TestWithUnknownFieldsNotify(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		Reply(200).
+		File("fixtures/200-list-types-boards-unknown-fields.json")
+
+	var unknown []string
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"),
+		WithUnknownFieldsNotify(func(fields []string) {
+			unknown = fields
+		}),
+	)
+	resp, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, "AB", resp.Boards[0].Code)
+	// Paging fields aren't decoded by ListBoardsResponse.
+	assert.Equal(t, []string{"boards[].shortName", "from", "to", "total"}, unknown)
+}
+
+func TestWithUnknownEnumNotify(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-api/1.0/bookings/207-12306403").
+		Reply(200).
+		BodyString(`{"booking": {"reference": "207-12306403", "status": "ON_REQUEST", "hotel": {"rooms": [
+			{"status": "CONFIRMED", "rates": [{"paymentType": "AT_WEB"}, {"paymentType": "AT_AGENCY"}]}
+		]}}}`)
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/hotels/6613/details").
+		Reply(200).
+		BodyString(`{"hotels": [{"code": 6613, "phones": [{"phoneNumber": "+34971", "phoneType": "PHONEMOBILE"}]}]}`)
+
+	type unknownEnum struct{ enumType, value string }
+	var unknown []unknownEnum
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"),
+		WithUnknownEnumNotify(func(enumType, value string) {
+			unknown = append(unknown, unknownEnum{enumType: enumType, value: value})
+		}),
+	)
+	resp, err := client.GetBooking(context.TODO(), "207-12306403")
+	assert.NoError(t, err)
+	assert.Equal(t, BookingStatus("ON_REQUEST"), resp.Booking.Status)
+	assert.Equal(t, PaymentType("AT_AGENCY"), resp.Booking.Hotel.Rooms[0].Rates[1].PaymentType)
+	assert.Equal(t, []unknownEnum{
+		{enumType: "BookingStatus", value: "ON_REQUEST"},
+		{enumType: "PaymentType", value: "AT_AGENCY"},
+	}, unknown)
+
+	_, err = client.GetHotelDetails(context.TODO(), []int{6613}, &GetHotelDetailsInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, unknownEnum{enumType: "PhoneType", value: "PHONEMOBILE"}, unknown[2])
+
+	// Other clients aren't affected.
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-api/1.0/bookings/207-12306403").
+		Reply(200).
+		BodyString(`{"booking": {"reference": "207-12306403", "status": "ON_REQUEST"}}`)
+	_, err = New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET")).GetBooking(context.TODO(), "207-12306403")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(unknown))
+}
+
+func TestWithUnmarshaler(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		Times(2).
+		Reply(200).
+		File("fixtures/200-list-types-boards-unknown-fields.json")
+
+	var calls int
+	unmarshaler := UnmarshalerFunc(func(data []byte, v any) error {
+		calls++
+		return json.Unmarshal(data, v)
+	})
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"), WithUnmarshaler(unmarshaler))
+	resp, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, "AB", resp.Boards[0].Code)
+	assert.Equal(t, 1, calls)
+
+	// Unmarshaler is used along with unknown fields detection.
+	var unknown []string
+	client = New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"), WithUnmarshaler(unmarshaler),
+		WithUnknownFieldsNotify(func(fields []string) {
+			unknown = fields
+		}))
+	resp, err = client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, "AB", resp.Boards[0].Code)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []string{"boards[].shortName", "from", "to", "total"}, unknown)
+}
+
+func TestWithLanguage(t *testing.T) {
+	defer gock.Off()
+
+	for _, lang := range []string{"CAS", "ENG"} {
+		lang := lang
+		gock.New("https://api.test.hotelbeds.com").
+			Post("/hotel-api/1.0/hotels").
+			AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+				body, err := io.ReadAll(req.Body)
+				if err != nil {
+					return false, err
+				}
+				return strings.Contains(string(body), `"language":"`+lang+`"`), nil
+			}).
+			Reply(200).
+			File("fixtures/200-list-available-hotels.json")
+	}
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"), WithLanguage("CAS"))
+	inp := &ListAvailableHotelsInput{
+		Stay: Stay{
+			CheckIn:  "2024-04-02",
+			CheckOut: "2024-04-03",
+		},
+		Occupancies: []Occupancy{{Rooms: 1, Adults: 1}},
+		Hotels:      FilterHotel{HotelCodes: []int{6619}},
+	}
+	_, err := client.ListAvailableHotels(context.TODO(), inp)
+	assert.NoError(t, err)
+	assert.Empty(t, inp.Language)
+
+	// Language of the input overrides the default one.
+	inp.Language = "ENG"
+	_, err = client.ListAvailableHotels(context.TODO(), inp)
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+}
+
+func TestWithDefaultCreationUser(t *testing.T) {
+	defer gock.Off()
+
+	for _, user := range []string{"DefaultAgent", "CallAgent"} {
+		user := user
+		gock.New("https://api.test.hotelbeds.com").
+			Post("/hotel-api/1.2/bookings").
+			AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+				body, err := io.ReadAll(req.Body)
+				if err != nil {
+					return false, err
+				}
+				return strings.Contains(string(body), `"creationUser":"`+user+`"`), nil
+			}).
+			Reply(200).
+			File("fixtures/200-confirm-booking.json")
+	}
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"), WithDefaultCreationUser("DefaultAgent"))
+	inp := &ConfirmBookingInput{
+		Holder:          Holder{Name: "HolderFirstName", Surname: "HolderLastName"},
+		ClientReference: "IntegrationAgency",
+		Rooms: []ConfirmBookingRoom{
+			{RateKey: "20240406|20240408|W|506|712986|DBL.DX|ID_B2B_26|BB||1~2~0||"},
+		},
+	}
+	_, err := client.ConfirmBooking(context.TODO(), inp)
+	assert.NoError(t, err)
+	assert.Empty(t, inp.CreationUser)
+
+	// Creation user of the input overrides the default one.
+	inp.CreationUser = "CallAgent"
+	_, err = client.ConfirmBooking(context.TODO(), inp)
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+}
+
+func TestWithSignatureResolution(t *testing.T) {
+	now := time.Date(2024, 4, 1, 12, 0, 0, 100*int(time.Millisecond), time.UTC)
+	clock := func() time.Time { return now }
+
+	api := New("key", "secret", WithClock(clock)).(*API)
+	signature := api.hashSignature()
+
+	// Calls within the same second share the signature.
+	now = now.Add(800 * time.Millisecond)
+	assert.Equal(t, signature, api.hashSignature())
+
+	// Calls across the second boundary differ.
+	now = now.Add(100 * time.Millisecond)
+	assert.NotEqual(t, signature, api.hashSignature())
+
+	now = time.Date(2024, 4, 1, 12, 0, 1, 0, time.UTC)
+	api = New("key", "secret", WithClock(clock), WithSignatureResolution(5*time.Second)).(*API)
+	signature = api.hashSignature()
+
+	now = now.Add(3 * time.Second)
+	assert.Equal(t, signature, api.hashSignature())
+
+	now = now.Add(time.Second)
+	assert.NotEqual(t, signature, api.hashSignature())
+}
+
+func TestWithClockResync(t *testing.T) {
+	defer gock.Off()
+
+	serverTime := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	localTime := serverTime.Add(-time.Hour)
+	signature := func(now time.Time) string {
+		sum := sha256.Sum256([]byte(signaturePayload("key", "secret", now)))
+		return hex.EncodeToString(sum[:])
+	}
+
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.0/checkrates").
+		MatchHeader("X-Signature", signature(localTime)).
+		Reply(403).
+		SetHeader("Date", serverTime.Format(http.TimeFormat)).
+		JSON(map[string]string{"error": "Request signature has expired"})
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.0/checkrates").
+		MatchHeader("X-Signature", signature(serverTime)).
+		BodyString(`"rateKey":"20240402|20240403|W|164|6619|TWN.ST|BAR BB FLEX 14|BB||1~1~0||"`).
+		Reply(200).
+		File("fixtures/200-list-checkrates.json")
+
+	var notified []int
+	client := New("key", "secret", WithClock(func() time.Time { return localTime }), WithClockResync(),
+		WithRetryNotify(func(attempt int, err error, wait time.Duration) {
+			notified = append(notified, attempt)
+		}))
+	resp, err := client.ListCheckRates(context.TODO(), &ListCheckRatesInput{
+		Rooms: []ListCheckRatesRoom{{RateKey: "20240402|20240403|W|164|6619|TWN.ST|BAR BB FLEX 14|BB||1~1~0||"}},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, resp)
+	assert.True(t, gock.IsDone())
+	assert.Equal(t, []int{1}, notified)
+
+	// Subsequent requests are signed with the corrected clock.
+	assert.Equal(t, serverTime, client.(*API).now())
+
+	for _, tc := range []struct {
+		name       string
+		ctx        context.Context
+		serverTime time.Time
+		message    string
+	}{
+		{name: "not a signature error", ctx: context.TODO(), serverTime: serverTime.Add(time.Hour),
+			message: "Access to this API has been disallowed"},
+		{name: "invalid signature", ctx: context.TODO(), serverTime: serverTime.Add(time.Hour),
+			message: "Request signature verification failed"},
+		{name: "skew within resolution", ctx: context.TODO(), serverTime: serverTime.Add(time.Second),
+			message: "Request signature has expired"},
+		{name: "credentials override", ctx: ContextWithCredentials(context.TODO(), "tenant-key", "tenant-secret"),
+			serverTime: serverTime.Add(time.Hour), message: "Request signature has expired"},
+	} {
+		gock.New("https://api.test.hotelbeds.com").
+			Get("/hotel-content-api/1.0/types/boards").
+			Reply(403).
+			SetHeader("Date", tc.serverTime.Format(http.TimeFormat)).
+			JSON(map[string]string{"error": tc.message})
+		_, err = client.ListBoards(tc.ctx, &ListBoardsInput{})
+		assert.Error(t, err, tc.name)
+		assert.True(t, gock.IsDone(), tc.name)
+		assert.Equal(t, serverTime, client.(*API).now(), tc.name)
+	}
+	assert.Equal(t, []int{1}, notified)
+
+	// Confirmation rejected by the signature isn't processed, so it's resent as well.
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.2/bookings").
+		Reply(403).
+		SetHeader("Date", serverTime.Add(time.Hour).Format(http.TimeFormat)).
+		JSON(map[string]string{"error": "Request signature has expired"})
+	gock.New("https://api.test.hotelbeds.com").
+		Post("/hotel-api/1.2/bookings").
+		MatchHeader("X-Signature", signature(serverTime.Add(time.Hour))).
+		Reply(200).
+		File("fixtures/200-confirm-booking.json")
+	var resyncErr error
+	client = New("key", "secret", WithClock(func() time.Time { return localTime }), WithClockResync(),
+		WithRetryNotify(func(attempt int, err error, wait time.Duration) {
+			resyncErr = err
+		}))
+	_, err = client.ConfirmBooking(context.TODO(), &ConfirmBookingInput{
+		Holder:          Holder{Name: "HolderFirstName", Surname: "HolderLastName"},
+		ClientReference: "IntegrationAgency",
+		Rooms: []ConfirmBookingRoom{
+			{RateKey: "20240406|20240408|W|506|712986|DBL.DX|ID_B2B_26|BB||1~2~0||"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+	var apiErr *Error
+	assert.ErrorAs(t, resyncErr, &apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.False(t, apiErr.IsRetryable)
+}
+
+func TestMustSetEnvironment(t *testing.T) {
+	defer gock.Off()
+
+	_, err := NewClient("key", "secret", MustSetEnvironment())
+	assert.ErrorIs(t, err, ErrEnvironmentNotSet)
+	// New never fails, the test environment is used.
+	assert.NotPanics(t, func() {
+		New("key", "secret", MustSetEnvironment())
+	})
+
+	gock.New("https://api.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		Reply(200).
+		File("fixtures/200-list-types-boards.json")
+
+	client, err := NewClient("key", "secret", MustSetEnvironment(), WithEnvironment(EnvironmentProduction))
+	assert.NoError(t, err)
+	_, err = client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+
+	// Test environment is used by default.
+	_, err = NewClient("key", "secret")
+	assert.NoError(t, err)
+}
+
+func TestWithAcceptContentType(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		MatchHeader("Accept", "^application/json$").
+		MatchHeader("Content-Type", "^application/json$").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			// Accept-Encoding is left to the transport.
+			return req.Header.Get("Accept-Encoding") != "application/json", nil
+		}).
+		Reply(200).
+		File("fixtures/200-list-types-boards.json")
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		MatchHeader("Accept", `^application/json; charset=utf-8$`).
+		MatchHeader("Content-Type", `^application/vnd\.hotelbeds\+json$`).
+		Reply(200).
+		File("fixtures/200-list-types-boards.json")
+
+	client := New("key", "secret")
+	_, err := client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+
+	client = New("key", "secret", WithAccept("application/json; charset=utf-8"), WithContentType("application/vnd.hotelbeds+json"))
+	_, err = client.ListBoards(context.TODO(), &ListBoardsInput{})
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+}
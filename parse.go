@@ -3,24 +3,28 @@
 // that can be found in the LICENSE file.
 package hotelbeds
 
-import "strings"
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 const phoneE164Length = 8
 
+// phoneDelimiters are characters HotelBeds uses to separate phone number parts.
+const phoneDelimiters = ".-, ()"
+
 // ParseE164 validates HotelBeds-styled phone number and converts into international E164 phone number.
-func ParseE163(raw string) string {
-	var e164Number string
-	delimPos := strings.IndexAny(raw, ".-, ")
-	if delimPos > 0 {
-		char := raw[delimPos]
-		if char != 0 { // EOF
-			e164Number = strings.ReplaceAll(raw, string(char), "")
-		} else {
-			e164Number = raw
+// Returns empty string if number is invalid.
+func ParseE164(raw string) string {
+	e164Number := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(phoneDelimiters, r) {
+			return -1
 		}
-	} else {
-		e164Number = raw
-	}
+		return r
+	}, raw)
 	if len(e164Number) < phoneE164Length {
 		return ""
 	}
@@ -38,6 +42,102 @@ func ParseE163(raw string) string {
 	if formattedNumber[0] != '+' {
 		formattedNumber = "+" + formattedNumber
 	}
+	for _, r := range formattedNumber[1:] {
+		if r < '0' || r > '9' {
+			return ""
+		}
+	}
 
 	return formattedNumber
 }
+
+// ParseE163 is misspelled alias of ParseE164.
+//
+// Deprecated: use ParseE164 instead.
+func ParseE163(raw string) string {
+	return ParseE164(raw)
+}
+
+// RateKeyInfo represents information encoded into the rateKey returned by Availability.
+type RateKeyInfo struct {
+	CheckIn      Datetime
+	CheckOut     Datetime
+	HotelCode    int
+	RoomCode     string
+	RateCode     string
+	BoardCode    string
+	Rooms        int
+	Adults       int
+	Children     int
+	ChildrenAges []int
+}
+
+// Minimal number of "|" separated segments of the rateKey (till the children ages segment).
+const rateKeyMinSegments = 11
+
+var (
+	ErrInvalidRateKey  = errors.New("invalid rateKey")
+	ErrRateKeyMismatch = errors.New("rateKey mismatch")
+)
+
+// ParseRateKey parses rateKey like "20240402|20240403|W|164|6619|TWN.ST|BAR BB FLEX 14|BB||1~2~1|8|N@06~...".
+func ParseRateKey(key string) (RateKeyInfo, error) {
+	segments := strings.Split(key, "|")
+	if len(segments) < rateKeyMinSegments {
+		return RateKeyInfo{}, ErrInvalidRateKey
+	}
+
+	var info RateKeyInfo
+	checkIn, err := time.Parse("20060102", segments[0])
+	if err != nil {
+		return RateKeyInfo{}, fmt.Errorf("%w: checkIn: %v", ErrInvalidRateKey, err)
+	}
+	checkOut, err := time.Parse("20060102", segments[1])
+	if err != nil {
+		return RateKeyInfo{}, fmt.Errorf("%w: checkOut: %v", ErrInvalidRateKey, err)
+	}
+	info.CheckIn, info.CheckOut = Datetime(checkIn), Datetime(checkOut)
+	if info.HotelCode, err = strconv.Atoi(segments[4]); err != nil {
+		return RateKeyInfo{}, fmt.Errorf("%w: hotel code: %v", ErrInvalidRateKey, err)
+	}
+	info.RoomCode, info.RateCode, info.BoardCode = segments[5], segments[6], segments[7]
+
+	// Occupancy is encoded as rooms~adults~children.
+	occupancy := strings.Split(segments[9], "~")
+	if len(occupancy) != 3 {
+		return RateKeyInfo{}, fmt.Errorf("%w: occupancy %q", ErrInvalidRateKey, segments[9])
+	}
+	for i, dst := range []*int{&info.Rooms, &info.Adults, &info.Children} {
+		if *dst, err = strconv.Atoi(occupancy[i]); err != nil {
+			return RateKeyInfo{}, fmt.Errorf("%w: occupancy: %v", ErrInvalidRateKey, err)
+		}
+	}
+
+	// Children ages are encoded as age~age~...
+	if segments[10] != "" {
+		for _, elem := range strings.Split(segments[10], "~") {
+			age, err := strconv.Atoi(elem)
+			if err != nil {
+				return RateKeyInfo{}, fmt.Errorf("%w: children ages: %v", ErrInvalidRateKey, err)
+			}
+			info.ChildrenAges = append(info.ChildrenAges, age)
+		}
+	}
+	return info, nil
+}
+
+// Occupancy returns occupancy encoded into the rateKey. Children are returned as Paxes with their ages.
+func (info RateKeyInfo) Occupancy() Occupancy {
+	occ := Occupancy{
+		Rooms:    info.Rooms,
+		Adults:   info.Adults,
+		Children: info.Children,
+	}
+	for _, age := range info.ChildrenAges {
+		occ.Paxes = append(occ.Paxes, Pax{
+			Type: PaxTypeChildren,
+			Age:  age,
+		})
+	}
+	return occ
+}
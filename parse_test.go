@@ -0,0 +1,67 @@
+// Copyright (c) 2024 0x9ef. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+package hotelbeds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseE164(t *testing.T) {
+	for raw, want := range map[string]string{
+		"0034971211100":     "+34971211100",
+		"+0034971211100":    "+34971211100",
+		"+34 971-21.11,00":  "+34971211100",
+		"+44 (20) 75894700": "+442075894700",
+		"971211100":         "+971211100",
+		"123":               "",
+		"+34 971 ABC 100":   "",
+	} {
+		assert.Equal(t, want, ParseE164(raw), raw)
+	}
+}
+
+func TestParseRateKeyOccupancy(t *testing.T) {
+	for _, tc := range []struct {
+		key string
+		occ Occupancy
+	}{
+		{
+			key: "20240402|20240403|W|164|6619|TWN.ST|BAR BB FLEX 14|BB||1~1~0||N@06~~21e12c~1630615603~S~~~NOR~5F05A4B7D40E44A170871765642600AADE00000010000000006248118",
+			occ: Occupancy{Rooms: 1, Adults: 1},
+		},
+		{
+			key: "20240402|20240403|W|164|6619|DBL.ST|BAR BB FLEX 14|BB||2~4~0||N@06~~21e12c",
+			occ: Occupancy{Rooms: 2, Adults: 4},
+		},
+		{
+			key: "20240402|20240403|W|164|6619|DBL.ST|BAR BB FLEX 14|BB||1~2~1|8|N@06~~21e12c",
+			occ: Occupancy{Rooms: 1, Adults: 2, Children: 1, Paxes: []Pax{{Type: PaxTypeChildren, Age: 8}}},
+		},
+		{
+			key: "20240402|20240403|W|164|6619|FAM.ST|BAR RO|RO||1~2~2|5~11|N@06~~21e12c",
+			occ: Occupancy{Rooms: 1, Adults: 2, Children: 2, Paxes: []Pax{{Type: PaxTypeChildren, Age: 5}, {Type: PaxTypeChildren, Age: 11}}},
+		},
+	} {
+		info, err := ParseRateKey(tc.key)
+		assert.NoError(t, err)
+		assert.Equal(t, 6619, info.HotelCode)
+		assert.Equal(t, "2024-04-02", info.CheckIn.String())
+		assert.Equal(t, "2024-04-03", info.CheckOut.String())
+		assert.Equal(t, tc.occ, info.Occupancy())
+	}
+}
+
+func TestParseRateKeyInvalid(t *testing.T) {
+	for _, key := range []string{
+		"",
+		"20240402|20240403|W|164|6619|TWN.ST",
+		"20240402|20240403|W|164|6619|TWN.ST|BAR BB FLEX 14|BB||1~1||N@06",
+		"20240402|20240403|W|164|6619|TWN.ST|BAR BB FLEX 14|BB||1~1~1|x|N@06",
+	} {
+		_, err := ParseRateKey(key)
+		assert.ErrorIs(t, err, ErrInvalidRateKey)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2024 0x9ef. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+package hotelbeds
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ReferenceData contains records of reference data tables (types, locations).
+type ReferenceData struct {
+	Countries          []Country
+	Destinations       []Destination
+	Accommodations     []Accommodation
+	Boards             []Board
+	BoardGroups        []BoardGroup
+	Categories         []Category
+	Chains             []Chain
+	Classifications    []Classification
+	Currencies         []Currency
+	Facilities         []Facility
+	FacilityGroups     []FacilityGroup
+	FacilityTypologies []FacilityTypology
+	ImageTypes         []ImageType
+	Issues             []Issue
+	Languages          []Language
+	Promotions         []Promotion
+	Rooms              []Room
+	RateComments       []RateComment
+	Segments           []Segment
+	Terminals          []Terminal
+}
+
+// syncPageSize is the maximal number of records returned by reference data endpoints at once.
+const syncPageSize = 1000
+
+// SyncReferenceData fetches records of all reference data tables, which were
+// added or modified since the checkpoint, so reference data can be synced incrementally.
+// Tables are fetched one by one, all pages of every table are fetched.
+func (api *API) SyncReferenceData(ctx context.Context, since time.Time) (*ReferenceData, error) {
+	var data ReferenceData
+	tables := []struct {
+		table string
+		list  func(inp ListInput) (int, error)
+	}{
+		{table: "countries", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListCountries(ctx, &ListCountriesInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Countries = append(data.Countries, resp.Countries...)
+			return len(resp.Countries), nil
+		}},
+		{table: "destinations", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListDestinations(ctx, &ListDestinationsInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Destinations = append(data.Destinations, resp.Destinations...)
+			return len(resp.Destinations), nil
+		}},
+		{table: "accommodations", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListAccommodations(ctx, &ListAccommodationsInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Accommodations = append(data.Accommodations, resp.Accommodations...)
+			return len(resp.Accommodations), nil
+		}},
+		{table: "boards", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListBoards(ctx, &ListBoardsInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Boards = append(data.Boards, resp.Boards...)
+			return len(resp.Boards), nil
+		}},
+		{table: "board groups", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListBoardGroups(ctx, &ListBoardGroupsInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.BoardGroups = append(data.BoardGroups, resp.Groups...)
+			return len(resp.Groups), nil
+		}},
+		{table: "categories", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListCategories(ctx, &ListCategoriesInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Categories = append(data.Categories, resp.Categories...)
+			return len(resp.Categories), nil
+		}},
+		{table: "chains", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListChains(ctx, &ListChainsInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Chains = append(data.Chains, resp.Chains...)
+			return len(resp.Chains), nil
+		}},
+		{table: "classifications", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListClassifications(ctx, &ListClassificationsInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Classifications = append(data.Classifications, resp.Classifications...)
+			return len(resp.Classifications), nil
+		}},
+		{table: "currencies", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListCurrencies(ctx, &ListCurrenciesInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Currencies = append(data.Currencies, resp.Currencies...)
+			return len(resp.Currencies), nil
+		}},
+		{table: "facilities", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListFacilities(ctx, &ListFacilitiesInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Facilities = append(data.Facilities, resp.Facilities...)
+			return len(resp.Facilities), nil
+		}},
+		{table: "facility groups", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListFacilityGroups(ctx, &ListFacilityGroupsInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.FacilityGroups = append(data.FacilityGroups, resp.Groups...)
+			return len(resp.Groups), nil
+		}},
+		{table: "facility typologies", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListFacilityTypologies(ctx, &ListFacilityTypologiesInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.FacilityTypologies = append(data.FacilityTypologies, resp.Typologies...)
+			return len(resp.Typologies), nil
+		}},
+		{table: "image types", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListImageTypes(ctx, &ListImageTypesInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.ImageTypes = append(data.ImageTypes, resp.Types...)
+			return len(resp.Types), nil
+		}},
+		{table: "issues", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListIssues(ctx, &ListIssuesInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Issues = append(data.Issues, resp.Issues...)
+			return len(resp.Issues), nil
+		}},
+		{table: "languages", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListLanguages(ctx, &ListLanguagesInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Languages = append(data.Languages, resp.Languages...)
+			return len(resp.Languages), nil
+		}},
+		{table: "promotions", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListPromotions(ctx, &ListPromotionsInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Promotions = append(data.Promotions, resp.Promotions...)
+			return len(resp.Promotions), nil
+		}},
+		{table: "rooms", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListRooms(ctx, &ListRoomsInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Rooms = append(data.Rooms, resp.Rooms...)
+			return len(resp.Rooms), nil
+		}},
+		{table: "rate comments", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListRateComments(ctx, &ListRateCommentsInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.RateComments = append(data.RateComments, resp.RateComments...)
+			return len(resp.RateComments), nil
+		}},
+		{table: "segments", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListSegments(ctx, &ListSegmentsInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Segments = append(data.Segments, resp.Segments...)
+			return len(resp.Segments), nil
+		}},
+		{table: "terminals", list: func(inp ListInput) (int, error) {
+			resp, err := api.ListTerminals(ctx, &ListTerminalsInput{ListInput: inp})
+			if err != nil {
+				return 0, err
+			}
+			data.Terminals = append(data.Terminals, resp.Terminals...)
+			return len(resp.Terminals), nil
+		}},
+	}
+
+	lastUpdateTime := Datetime(since)
+	for _, table := range tables {
+		for from := 1; ; from += syncPageSize {
+			n, err := table.list(ListInput{
+				Language:       api.options.Language,
+				From:           from,
+				To:             from + syncPageSize - 1,
+				LastUpdateTime: &lastUpdateTime,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to sync %s: %w", table.table, err)
+			}
+			if n < syncPageSize {
+				break
+			}
+		}
+	}
+	return &data, nil
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2024 0x9ef. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+package hotelbeds
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestSyncReferenceData(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/locations/countries").
+		MatchParams(map[string]string{"lastUpdateTime": "2024-03-01", "from": "1", "to": "1000"}).
+		Reply(200).
+		File("fixtures/200-list-locations-countries.json")
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/types/boards").
+		MatchParam("lastUpdateTime", "2024-03-01").
+		Reply(200).
+		File("fixtures/200-list-types-boards.json")
+	// Other tables have no changes since the checkpoint.
+	gock.New("https://api.test.hotelbeds.com").
+		Get("/hotel-content-api/1.0/(types|locations)/").
+		MatchParam("lastUpdateTime", "2024-03-01").
+		Persist().
+		Reply(200).
+		JSON(map[string]any{})
+
+	client := New(os.Getenv("HOTELBEDS_API_KEY"), os.Getenv("HOTELBEDS_API_SECRET"))
+	data, err := client.(*API).SyncReferenceData(context.TODO(), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, data.Countries)
+	assert.NotEmpty(t, data.Boards)
+	assert.Empty(t, data.Destinations)
+	assert.Empty(t, data.Terminals)
+}